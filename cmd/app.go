@@ -5,17 +5,32 @@ import "github.com/urfave/cli/v3"
 // NewApp creates the root ignlnk CLI command with all subcommands.
 func NewApp() *cli.Command {
 	return &cli.Command{
-		Name:  "ignlnk",
-		Usage: "Protect sensitive files from AI coding agents",
+		Name:   "ignlnk",
+		Usage:  "Protect sensitive files from AI coding agents",
+		Before: expireUnlocks,
 		Commands: []*cli.Command{
 			initCmd(),
 			lockCmd(),
 			unlockCmd(),
 			statusCmd(),
 			listCmd(),
+			verifyCmd(),
+			repairCmd(),
+			recoverCmd(),
 			forgetCmd(),
+			commitCmd(),
+			relocateCmd(),
 			lockAllCmd(),
 			unlockAllCmd(),
+			historyCmd(),
+			restoreCmd(),
+			vaultCmd(),
+			watchCmd(),
+			execCmd(),
+			guardCmd(),
+			sessionCmd(),
+			leaksCmd(),
+			privateHelperCmd(),
 		},
 	}
 }
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/user/ignlnk/internal/core"
+)
+
+// TestMain lets the test binary stand in for ignlnk when 'exec --private' re-executes
+// it as its namespace helper.
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == privateHelperName {
+		if err := run(os.Args[1:]...); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// setupProject runs 'ignlnk init' in a fresh project directory, made the working
+// directory, with a fresh home directory (and so a fresh vault). files (path -> content)
+// are created in the project. Returns the project root.
+func setupProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	if err := core.CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("IGNLNK_PASSPHRASE", "")
+	root := t.TempDir()
+	t.Chdir(root)
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := run("init"); err != nil {
+		t.Fatalf("init failed: %v", err)
+	}
+	return root
+}
+
+// run runs ignlnk with args.
+func run(args ...string) error {
+	return NewApp().Run(context.Background(), append([]string{"ignlnk"}, args...))
+}
+
+// runOutput runs ignlnk with args and returns what it printed to stdout.
+func runOutput(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	runErr := run(args...)
+	os.Stdout = stdout
+	w.Close()
+	out, err := io.ReadAll(r)
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), runErr
+}
+
+// loadState returns the manifest of the project in the working directory.
+func loadState(t *testing.T) *core.Manifest {
+	t.Helper()
+	project, err := core.FindProject(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := project.LoadManifest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manifest
+}
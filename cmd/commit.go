@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func commitCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "commit",
+		Usage:     "Accept edits made to unlocked files without re-locking",
+		ArgsUsage: "<path>...",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			args := cmd.Args().Slice()
+			if len(args) == 0 {
+				return fmt.Errorf("no files specified")
+			}
+
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+
+			unlock, err := project.LockManifest()
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			manifest, err := loadManifest(project, vault)
+			if err != nil {
+				return err
+			}
+
+			cleanup := installSignalHandler(project, manifest)
+			defer cleanup()
+
+			succeeded := 0
+			failed := 0
+
+			for _, arg := range args {
+				relPath, err := core.ManagedPath(project, vault, manifest, arg)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", arg, err)
+					failed++
+					continue
+				}
+
+				changed, err := core.CommitFile(project, vault, manifest, relPath)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+					failed++
+					continue
+				}
+
+				if changed {
+					fmt.Printf("committed: %s\n", managedPath(vault, relPath, manifest.Files[relPath]))
+				} else {
+					fmt.Printf("unchanged: %s\n", managedPath(vault, relPath, manifest.Files[relPath]))
+				}
+				succeeded++
+			}
+
+			if err := project.SaveManifest(manifest); err != nil {
+				return fmt.Errorf("saving manifest: %w", err)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d files committed, %d failed", succeeded, len(args), failed)
+			}
+			return nil
+		},
+	}
+}
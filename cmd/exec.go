@@ -0,0 +1,398 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+// ExitStatus makes ignlnk exit with a child process's status without printing an error
+// of its own (see main).
+type ExitStatus int
+
+func (e ExitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// Private exec: ignlnk re-executes itself as privateHelperName inside new namespaces
+// (see exec_private_linux.go), passing the mounts in privateBindsEnv.
+const (
+	privateHelperName = "private-exec-helper"
+	privateBindsEnv   = "IGNLNK_PRIVATE_BINDS"
+)
+
+// privateBind mounts plaintext file Src over the placeholder at Dst.
+type privateBind struct {
+	RelPath string `json:"relPath"`
+	Src     string `json:"src"`
+	Dst     string `json:"dst"`
+}
+
+func execCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "exec",
+		Usage:     "Unlock files only while a command runs, then restore their previous states",
+		ArgsUsage: "-- <cmd> [args...]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "files",
+				Usage: "Managed files to unlock (repeatable; default: every locked file)",
+			},
+			&cli.BoolFlag{
+				Name:  "private",
+				Usage: "Linux: show the files only to the command, bind-mounted in a private user+mount namespace; the tree stays locked for every other process",
+			},
+			&cli.StringFlag{
+				Name:  "override",
+				Usage: "Unlock despite an active session, recording this reason",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			args := cmd.Args().Slice()
+			if len(args) == 0 {
+				return fmt.Errorf("no command specified (usage: ignlnk exec [--files <path>]... -- <cmd> [args...])")
+			}
+
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+
+			runner := newChildRunner()
+			defer runner.Close()
+
+			private := cmd.Bool("private")
+			var unlocked []string
+			var binds []privateBind
+			if private {
+				binds, err = prepareForPrivateExec(project, vault, cmd.StringSlice("files"), cmd.String("override"))
+			} else {
+				unlocked, err = unlockForExec(project, vault, cmd.StringSlice("files"), cmd.String("override"), runner.Interrupted)
+			}
+
+			var runErr error
+			ran := false
+			if err == nil && !runner.Interrupted() {
+				ran = true
+				c := exec.Command(args[0], args[1:]...)
+				if private {
+					c, runErr = privateCommand(binds, args)
+				}
+				if runErr == nil {
+					runErr = runner.start(c)
+					if runErr != nil && private {
+						runErr = fmt.Errorf("%w — user namespaces may be unavailable (see /proc/sys/user/max_user_namespaces); run without --private", runErr)
+					}
+				}
+				if runErr == nil {
+					runErr = runner.wait(c)
+				}
+			}
+
+			// Restore even after a failed unlock, an interrupt or a failed command
+			var restoreErr error
+			if private {
+				restoreErr = syncAfterPrivateExec(project, vault, binds)
+			} else {
+				restoreErr = relockAfterExec(project, vault, unlocked)
+			}
+			if restoreErr != nil {
+				return errors.Join(err, restoreErr)
+			}
+			switch {
+			case err != nil:
+				return err
+			case !ran:
+				return fmt.Errorf("interrupted — command not run")
+			}
+			return childExitStatus(args[0], runErr)
+		},
+	}
+}
+
+// childRunner runs a child process with ignlnk's signals forwarded to it. Signals that
+// arrive while no child runs only mark the run interrupted: ignlnk is never killed
+// mid-way (no os.Exit as in installSignalHandler), so callers always restore file states.
+type childRunner struct {
+	mu          sync.Mutex
+	child       *os.Process
+	interrupted bool
+	stop        func()
+}
+
+func newChildRunner() *childRunner {
+	r := &childRunner{}
+	r.stop = notifySignals(r.signal, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	return r
+}
+
+func (r *childRunner) signal(sig os.Signal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.child != nil {
+		r.child.Signal(sig)
+		return
+	}
+	r.interrupted = true
+}
+
+// Interrupted reports whether a signal arrived while no child was running.
+func (r *childRunner) Interrupted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.interrupted
+}
+
+// start starts c on the terminal's stdio; signals are forwarded until wait returns.
+func (r *childRunner) start(c *exec.Cmd) error {
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := c.Start(); err != nil {
+		return err
+	}
+	r.child = c.Process
+	return nil
+}
+
+func (r *childRunner) wait(c *exec.Cmd) error {
+	err := c.Wait()
+	r.mu.Lock()
+	r.child = nil
+	r.mu.Unlock()
+	return err
+}
+
+// Close stops handling signals.
+func (r *childRunner) Close() {
+	r.stop()
+}
+
+// unlockForExec unlocks the locked files among args (every locked file if empty),
+// subject to an active session (see gateUnlocks), and saves the manifest. Returns the files it unlocked, also on error, so they can be
+// re-locked. Stops early once stop reports true.
+func unlockForExec(project *core.Project, vault *core.Vault, args []string, override string, stop func() bool) ([]string, error) {
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := execTargets(project, vault, manifest, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := gateUnlocks(project, targets, override); err != nil {
+		return nil, err
+	}
+
+	var unlocked []string
+	for _, relPath := range targets {
+		if stop() {
+			break
+		}
+		if err = core.UnlockFile(project, vault, manifest, relPath); err != nil {
+			err = fmt.Errorf("%s: %w", filepath.FromSlash(relPath), err)
+			break
+		}
+		fmt.Fprintf(os.Stderr, "unlocked: %s\n", filepath.FromSlash(relPath))
+		unlocked = append(unlocked, relPath)
+	}
+
+	if saveErr := project.SaveManifest(manifest); saveErr != nil {
+		return unlocked, errors.Join(err, fmt.Errorf("saving manifest: %w", saveErr))
+	}
+	return unlocked, err
+}
+
+// execTargets resolves exec's --files to the locked files among them (every locked file
+// if none are given). Already unlocked files stay as they are, before and after.
+func execTargets(project *core.Project, vault *core.Vault, manifest *core.Manifest, args []string) ([]string, error) {
+	var targets []string
+	if len(args) == 0 {
+		for relPath, entry := range manifest.Files {
+			if entry.State == "locked" {
+				targets = append(targets, relPath)
+			}
+		}
+		sort.Strings(targets)
+	}
+	for _, arg := range args {
+		relPath, err := core.ManagedPath(project, vault, manifest, arg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", arg, err)
+		}
+		entry, ok := manifest.Files[relPath]
+		if !ok {
+			return nil, fmt.Errorf("file not managed: %s", filepath.FromSlash(relPath))
+		}
+		if entry.State == "locked" {
+			targets = append(targets, relPath)
+		}
+	}
+	return targets, nil
+}
+
+// prepareForPrivateExec creates a private view (see core.PrivateView) of each target,
+// subject to an active session like unlockForExec.
+// Nothing in the manifest changes: the files stay locked for everyone but the command.
+func prepareForPrivateExec(project *core.Project, vault *core.Vault, args []string, override string) ([]privateBind, error) {
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return nil, err
+	}
+	targets, err := execTargets(project, vault, manifest, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := gateUnlocks(project, targets, override); err != nil {
+		return nil, err
+	}
+
+	var binds []privateBind
+	for _, relPath := range targets {
+		src, err := core.PrivateView(project, vault, manifest, relPath)
+		if err != nil {
+			// Drop the views already prepared (decrypted working copies)
+			syncErr := syncPrivateViews(project, vault, manifest, binds)
+			return nil, errors.Join(fmt.Errorf("%s: %w", filepath.FromSlash(relPath), err), syncErr)
+		}
+		binds = append(binds, privateBind{RelPath: relPath, Src: src, Dst: project.AbsPath(relPath)})
+	}
+	return binds, nil
+}
+
+// syncAfterPrivateExec accepts edits the command made through its private views.
+func syncAfterPrivateExec(project *core.Project, vault *core.Vault, binds []privateBind) error {
+	if len(binds) == 0 {
+		return nil
+	}
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return fmt.Errorf("syncing private views: %w — run 'ignlnk verify'", err)
+	}
+	defer unlock()
+
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return fmt.Errorf("syncing private views: %w", err)
+	}
+	return syncPrivateViews(project, vault, manifest, binds)
+}
+
+func syncPrivateViews(project *core.Project, vault *core.Vault, manifest *core.Manifest, binds []privateBind) error {
+	failed := 0
+	for _, b := range binds {
+		changed, err := core.SyncPrivateView(project, vault, manifest, b.RelPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(b.RelPath), err)
+			failed++
+			continue
+		}
+		if changed {
+			fmt.Fprintf(os.Stderr, "synced: %s\n", filepath.FromSlash(b.RelPath))
+		}
+	}
+	if err := project.SaveManifest(manifest); err != nil {
+		return fmt.Errorf("saving manifest: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d files could not be synced — run 'ignlnk verify'", failed)
+	}
+	return nil
+}
+
+func privateHelperCmd() *cli.Command {
+	return &cli.Command{
+		Name:   privateHelperName,
+		Usage:  "Internal: runs inside the namespaces created by 'exec --private'",
+		Hidden: true,
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			args := cmd.Args().Slice()
+			if len(args) == 0 || os.Getenv(privateBindsEnv) == "" {
+				return fmt.Errorf("%s is internal to 'ignlnk exec --private'", privateHelperName)
+			}
+			return runPrivateHelper(args)
+		},
+	}
+}
+
+// relockAfterExec re-locks the files exec unlocked, accepting any edits the command made.
+func relockAfterExec(project *core.Project, vault *core.Vault, relPaths []string) error {
+	if len(relPaths) == 0 {
+		return nil
+	}
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return fmt.Errorf("re-locking: %w — run 'ignlnk lock-all'", err)
+	}
+	defer unlock()
+
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return fmt.Errorf("re-locking: %w", err)
+	}
+
+	failed := 0
+	for _, relPath := range relPaths {
+		if entry, ok := manifest.Files[relPath]; !ok || !entry.Unlocked() {
+			continue // Locked or forgotten by the command itself
+		}
+		if err := core.LockFile(project, vault, manifest, relPath, false); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+			failed++
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "locked: %s\n", filepath.FromSlash(relPath))
+	}
+
+	if err := project.SaveManifest(manifest); err != nil {
+		return fmt.Errorf("saving manifest: %w", err)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d files could not be re-locked — still unlocked", failed)
+	}
+	return nil
+}
+
+// childExitStatus converts the command's result into ignlnk's exit status: the child's
+// own status, or 128+signal if a signal killed it (as shells report it).
+func childExitStatus(name string, runErr error) error {
+	var exitErr *exec.ExitError
+	if !errors.As(runErr, &exitErr) {
+		if runErr != nil {
+			return fmt.Errorf("running %s: %w", name, runErr)
+		}
+		return nil
+	}
+	if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		return ExitStatus(128 + int(ws.Signal()))
+	}
+	return ExitStatus(exitErr.ExitCode())
+}
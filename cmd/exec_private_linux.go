@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// privateCommand returns a command that re-executes ignlnk as the hidden private-exec
+// helper in a new user and mount namespace, mapped to the caller's uid and gid. The
+// helper bind-mounts binds (passed via privateBindsEnv) and then execs args.
+func privateCommand(binds []privateBind, args []string) (*exec.Cmd, error) {
+	self, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("locating ignlnk executable: %w", err)
+	}
+	data, err := json.Marshal(binds)
+	if err != nil {
+		return nil, err
+	}
+
+	c := exec.Command(self, append([]string{privateHelperName, "--"}, args...)...)
+	c.Env = append(os.Environ(), privateBindsEnv+"="+string(data))
+	c.SysProcAttr = &syscall.SysProcAttr{
+		Cloneflags:                 syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS,
+		UidMappings:                []syscall.SysProcIDMap{{ContainerID: os.Getuid(), HostID: os.Getuid(), Size: 1}},
+		GidMappings:                []syscall.SysProcIDMap{{ContainerID: os.Getgid(), HostID: os.Getgid(), Size: 1}},
+		GidMappingsEnableSetgroups: false,
+	}
+	return c, nil
+}
+
+// runPrivateHelper runs inside the new namespaces: mounts the vault files over their
+// placeholders, visible only to this process tree, then replaces itself with args.
+func runPrivateHelper(args []string) error {
+	var binds []privateBind
+	if err := json.Unmarshal([]byte(os.Getenv(privateBindsEnv)), &binds); err != nil {
+		return fmt.Errorf("reading private mounts: %w", err)
+	}
+	os.Unsetenv(privateBindsEnv)
+
+	// Keep the mounts below from propagating back to the parent namespace
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return fmt.Errorf("making mounts private: %w", err)
+	}
+	for _, b := range binds {
+		if err := syscall.Mount(b.Src, b.Dst, "", syscall.MS_BIND, ""); err != nil {
+			return fmt.Errorf("bind-mounting %s: %w", b.Dst, err)
+		}
+	}
+
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+	return syscall.Exec(path, args, os.Environ())
+}
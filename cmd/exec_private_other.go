@@ -0,0 +1,17 @@
+//go:build !linux
+
+package cmd
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// privateCommand is unavailable: private exec needs Linux user and mount namespaces.
+func privateCommand(binds []privateBind, args []string) (*exec.Cmd, error) {
+	return nil, fmt.Errorf("--private needs Linux user and mount namespaces; run without --private")
+}
+
+func runPrivateHelper(args []string) error {
+	return fmt.Errorf("private exec is only supported on Linux")
+}
@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/user/ignlnk/internal/core"
+)
+
+// setupExecProject is setupProject with .env locked, for tests that run commands via sh.
+func setupExecProject(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available:", err)
+	}
+	root := setupProject(t, map[string]string{".env": "A=1"})
+	if err := run("lock", ".env"); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+// expectLocked fails unless root's .env is locked with its placeholder in place.
+func expectLocked(t *testing.T, root string) {
+	t.Helper()
+	if entry := loadState(t).Files[".env"]; entry.State != "locked" {
+		t.Fatalf("expected .env locked again, got %s", entry.State)
+	}
+	if !core.IsPlaceholder(filepath.Join(root, ".env")) {
+		t.Fatal("expected the placeholder back at .env")
+	}
+}
+
+func TestExecRelocksAfterFailedCommand(t *testing.T) {
+	root := setupExecProject(t)
+
+	// The command sees the real content, then fails
+	err := run("exec", "--", "sh", "-c", "grep -q A=1 .env || exit 9; exit 3")
+	var status ExitStatus
+	if !errors.As(err, &status) || status != 3 {
+		t.Fatalf("expected the command's exit status 3, got %v", err)
+	}
+	expectLocked(t, root)
+}
+
+func TestExecForwardsSignals(t *testing.T) {
+	root := setupExecProject(t)
+
+	// SIGTERM goes to ignlnk only; the command exits 7 once it is passed on
+	go func() {
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+			if _, err := os.Stat(filepath.Join(root, "started")); err == nil {
+				syscall.Kill(os.Getpid(), syscall.SIGTERM)
+				return
+			}
+		}
+	}()
+	err := run("exec", "--", "sh", "-c", "trap 'exit 7' TERM; touch started; while :; do sleep 0.05; done")
+	var status ExitStatus
+	if !errors.As(err, &status) || status != 7 {
+		t.Fatalf("expected the command to exit 7 on the forwarded SIGTERM, got %v", err)
+	}
+	expectLocked(t, root)
+}
+
+func TestExecPrivate(t *testing.T) {
+	root := setupExecProject(t)
+	hash := loadState(t).Files[".env"].Hash
+
+	// The command sees and edits the real content; the project keeps the placeholder
+	err := run("exec", "--private", "--", "sh", "-c", "grep -q A=1 .env || exit 9; printf A=2 > .env")
+	if err != nil && strings.Contains(err.Error(), "user namespaces may be unavailable") {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatalf("exec --private failed: %v", err)
+	}
+	expectLocked(t, root)
+	if loadState(t).Files[".env"].Hash == hash {
+		t.Fatal("expected the command's edit synced into the vault")
+	}
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+// expireUnlocks is the root Before hook: every invocation inside a project first re-locks
+// time-limited unlocks that have expired and records placeholder modifications for an
+// active session. Best-effort — problems are only warnings, and the command itself
+// reports a missing project or vault.
+func expireUnlocks(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	switch cmd.Args().First() {
+	case "", "help", "init", "watch", privateHelperName:
+		return ctx, nil
+	case "recover":
+		// Must see the journal exactly as the crash left it (e.g. for --discard)
+		return ctx, nil
+	}
+
+	project, err := core.FindProject(".")
+	if err != nil {
+		return ctx, nil
+	}
+	recordSessionActivity(project)
+
+	// Cheap unlocked check first; most invocations have nothing to re-lock
+	manifest, err := project.LoadManifest()
+	if err != nil || len(manifest.ExpiredFiles(time.Now())) == 0 {
+		return ctx, nil
+	}
+	vault, err := core.ResolveVault(project.Root)
+	if err != nil {
+		return ctx, nil
+	}
+	if _, _, err := relockExpired(project, vault); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: re-locking expired unlocks: %v\n", err)
+	}
+	return ctx, nil
+}
+
+// relockExpired re-locks every time-limited unlock that has expired, under the manifest
+// lock. An encrypted vault's edited working copy can only be sealed if the vault is
+// unsealed; otherwise the file is left unlocked with a warning. Returns the next pending
+// expiry, if any.
+func relockExpired(project *core.Project, vault *core.Vault) (time.Time, bool, error) {
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	defer unlock()
+
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	expired := manifest.ExpiredFiles(time.Now())
+	for _, relPath := range expired {
+		if err := core.LockFile(project, vault, manifest, relPath, true); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: unlock expired but re-lock failed: %v\n", filepath.FromSlash(relPath), err)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "re-locked (expired): %s\n", filepath.FromSlash(relPath))
+	}
+	if len(expired) > 0 {
+		if err := project.SaveManifest(manifest); err != nil {
+			return time.Time{}, false, fmt.Errorf("saving manifest: %w", err)
+		}
+	}
+
+	next, ok := manifest.NextExpiry()
+	return next, ok, nil
+}
+
+// expiryNote describes when a time-limited unlock re-locks, for status output.
+func expiryNote(entry *core.FileEntry, now time.Time) string {
+	t, ok := entry.Expiry()
+	if !ok {
+		return ""
+	}
+	if !now.Before(t) {
+		return " (expired)"
+	}
+	return fmt.Sprintf(" (re-locks in %s)", t.Sub(now).Round(time.Second))
+}
@@ -15,6 +15,12 @@ func forgetCmd() *cli.Command {
 		Name:      "forget",
 		Usage:     "Restore files from vault and remove from management",
 		ArgsUsage: "<path>...",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "override",
+				Usage: "Forget despite an active session, recording this reason",
+			},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			args := cmd.Args().Slice()
 			if len(args) == 0 {
@@ -29,6 +35,9 @@ func forgetCmd() *cli.Command {
 			if err != nil {
 				return err
 			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
 
 			unlock, err := project.LockManifest()
 			if err != nil {
@@ -36,7 +45,11 @@ func forgetCmd() *cli.Command {
 			}
 			defer unlock()
 
-			manifest, err := project.LoadManifest()
+			manifest, err := loadManifest(project, vault)
+			if err != nil {
+				return err
+			}
+			session, err := project.LoadSession()
 			if err != nil {
 				return err
 			}
@@ -48,26 +61,38 @@ func forgetCmd() *cli.Command {
 			failed := 0
 
 			for _, arg := range args {
-				relPath, err := project.RelPath(arg)
+				relPath, err := core.ManagedPath(project, vault, manifest, arg)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "error: %s: %v\n", arg, err)
 					failed++
 					continue
 				}
 
+				// Forgetting puts the plaintext back in the project, like an unlock
+				if err := sessionGate(session, relPath, cmd.String("override")); err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+					failed++
+					continue
+				}
+				shown := managedPath(vault, relPath, manifest.Files[relPath])
 				if err := core.ForgetFile(project, vault, manifest, relPath); err != nil {
 					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
 					failed++
 					continue
 				}
 
-				fmt.Printf("forgot: %s (restored to original location)\n", filepath.FromSlash(relPath))
+				fmt.Printf("forgot: %s (restored to original location)\n", shown)
 				succeeded++
 			}
 
 			if err := project.SaveManifest(manifest); err != nil {
 				return fmt.Errorf("saving manifest: %w", err)
 			}
+			if session != nil {
+				if err := project.SaveSession(session); err != nil {
+					return err
+				}
+			}
 
 			if failed > 0 {
 				return fmt.Errorf("%d of %d files forgotten, %d failed", succeeded, len(args), failed)
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+
+	"github.com/user/ignlnk/internal/core"
+)
+
+func TestForgetGatedBySession(t *testing.T) {
+	setupProject(t, map[string]string{".env": "A=1"})
+	if err := run("lock", ".env"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("session", "start", "agent"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run("forget", ".env"); err == nil {
+		t.Fatal("expected forget to be blocked by the session")
+	}
+	if entry := loadState(t).Files[".env"]; entry == nil || entry.State != "locked" {
+		t.Fatalf("expected .env still managed and locked, got %+v", entry)
+	}
+	if !core.IsPlaceholder(".env") {
+		t.Fatal("expected the placeholder kept")
+	}
+
+	if err := run("forget", "--override", "handing the file back", ".env"); err != nil {
+		t.Fatalf("forget --override failed: %v", err)
+	}
+	if data, err := os.ReadFile(".env"); err != nil || string(data) != "A=1" {
+		t.Fatalf("expected the original restored, got %q, %v", data, err)
+	}
+	project, err := core.FindProject(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := project.LoadSession()
+	if err != nil || session == nil {
+		t.Fatalf("expected the session still active: %v", err)
+	}
+	var kinds []string
+	for _, e := range session.Events {
+		if e.Path == ".env" {
+			kinds = append(kinds, e.Kind)
+		}
+	}
+	if len(kinds) != 2 || kinds[0] != core.EventUnlockBlocked || kinds[1] != core.EventUnlockOverride {
+		t.Fatalf("expected a blocked and an override event, got %+v", session.Events)
+	}
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func guardCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "guard",
+		Usage:     "Lock everything while an agent runs, then restore what was unlocked and report what it touched",
+		ArgsUsage: "-- <agent> [args...]",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			args := cmd.Args().Slice()
+			if len(args) == 0 {
+				return fmt.Errorf("no agent command specified (usage: ignlnk guard -- <agent> [args...])")
+			}
+
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+
+			runner := newChildRunner()
+			defer runner.Close()
+
+			snapshot, err := guardLockAll(project, vault)
+			if err != nil {
+				return err
+			}
+
+			var runErr error
+			ran := false
+			if !runner.Interrupted() {
+				ran = true
+				c := exec.Command(args[0], args[1:]...)
+				if runErr = runner.start(c); runErr == nil {
+					runErr = runner.wait(c)
+				}
+			}
+
+			// Restore even after an interrupt or a failed agent
+			if err := guardRestore(project, vault, snapshot); err != nil {
+				return err
+			}
+			if !ran {
+				return fmt.Errorf("interrupted — agent not started")
+			}
+			return childExitStatus(args[0], runErr)
+		},
+	}
+}
+
+// guardLockAll runs lockEverything under the manifest lock.
+func guardLockAll(project *core.Project, vault *core.Vault) (map[string]core.FileEntry, error) {
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return nil, err
+	}
+	return lockEverything(project, vault, manifest)
+}
+
+// lockEverything refuses while any file is tampered with or has uncommitted edits, then
+// locks every managed and .ignlnkfiles-matched file as one undoable batch and saves the
+// manifest. Returns the manifest entries as they were before.
+func lockEverything(project *core.Project, vault *core.Vault, manifest *core.Manifest) (map[string]core.FileEntry, error) {
+	keys := make([]string, 0, len(manifest.Files))
+	for k := range manifest.Files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var bad []string
+	for _, relPath := range keys {
+		if status := core.FileStatus(project, vault, manifest.Files[relPath], relPath, nil); status == "tampered" || status == "dirty" {
+			bad = append(bad, fmt.Sprintf("%s (%s)", filepath.FromSlash(relPath), status))
+		}
+	}
+	if len(bad) > 0 {
+		return nil, fmt.Errorf("refusing to start: %s — commit, lock or repair first", strings.Join(bad, ", "))
+	}
+
+	snapshot := make(map[string]core.FileEntry, len(manifest.Files))
+	var toLock []string
+	for _, relPath := range keys {
+		entry := manifest.Files[relPath]
+		snapshot[relPath] = *entry
+		if entry.Unlocked() {
+			toLock = append(toLock, relPath)
+		}
+	}
+	newFiles, err := discoverNewFiles(project, manifest)
+	if err != nil {
+		return nil, err
+	}
+	toLock = append(toLock, newFiles...)
+
+	batch := core.NewBatch(project, vault, manifest)
+	defer batch.Close()
+	for _, relPath := range toLock {
+		if err := batch.Lock(relPath, false); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+			return nil, rollbackBatch(project, manifest, batch, relPath)
+		}
+		fmt.Fprintf(os.Stderr, "locked: %s\n", filepath.FromSlash(relPath))
+	}
+
+	if err := project.SaveManifest(manifest); err != nil {
+		return nil, fmt.Errorf("saving manifest: %w", err)
+	}
+	return snapshot, nil
+}
+
+// guardRestore reports what the agent touched — managed files no longer intact
+// placeholders and unprotected new files matching .ignlnkfiles — then unlocks again the
+// files that were unlocked before, as they were. A file the agent changed stays locked.
+func guardRestore(project *core.Project, vault *core.Vault, snapshot map[string]core.FileEntry) error {
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return fmt.Errorf("restoring file states: %w", err)
+	}
+	defer unlock()
+
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return fmt.Errorf("restoring file states: %w", err)
+	}
+
+	keys := make([]string, 0, len(manifest.Files))
+	for k := range manifest.Files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	touched := make(map[string]bool)
+	for _, relPath := range keys {
+		entry := manifest.Files[relPath]
+		status := core.FileStatus(project, vault, entry, relPath, nil)
+		if entry.Unlocked() {
+			status = "unlocked during session"
+		}
+		if status != "locked" {
+			fmt.Fprintf(os.Stderr, "touched: %s (%s)\n", filepath.FromSlash(relPath), status)
+			touched[relPath] = true
+		}
+	}
+	newFiles, err := discoverNewFiles(project, manifest)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	}
+	for _, relPath := range newFiles {
+		fmt.Fprintf(os.Stderr, "new: %s (matches .ignlnkfiles, not locked)\n", filepath.FromSlash(relPath))
+		touched[relPath] = true
+	}
+	if len(touched) == 0 {
+		fmt.Fprintln(os.Stderr, "agent left every protected file untouched")
+	}
+
+	var errs []error
+	for _, relPath := range keys {
+		prev, ok := snapshot[relPath]
+		entry := manifest.Files[relPath]
+		if !ok || !prev.Unlocked() || entry.State != "locked" {
+			continue
+		}
+		if touched[relPath] {
+			fmt.Fprintf(os.Stderr, "left locked: %s (touched by the agent — inspect it, then unlock)\n", filepath.FromSlash(relPath))
+			continue
+		}
+		mode := core.UnlockModeSymlink
+		if prev.State == "unlocked-copy" {
+			mode = core.UnlockModeCopy
+		}
+		if err := core.UnlockFileAs(project, vault, manifest, relPath, mode, prev.ReadOnly); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+			errs = append(errs, err)
+			continue
+		}
+		manifest.Files[relPath].ExpiresAt = prev.ExpiresAt
+		fmt.Fprintf(os.Stderr, "unlocked: %s\n", filepath.FromSlash(relPath))
+	}
+
+	if err := project.SaveManifest(manifest); err != nil {
+		return fmt.Errorf("saving manifest: %w", err)
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%d files could not be unlocked again: %w", len(errs), errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGuardRefusesUncommittedEdits(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available:", err)
+	}
+	setupProject(t, map[string]string{".env": "A=1", "key.pem": "secret"})
+	if err := run("lock", ".env", "key.pem"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("unlock", ".env"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(".env", []byte("A=2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := run("guard", "--", "sh", "-c", "touch agent-ran")
+	if err == nil || !strings.Contains(err.Error(), "refusing to start") {
+		t.Fatalf("expected guard to refuse a dirty file, got %v", err)
+	}
+	if _, err := os.Stat("agent-ran"); err == nil {
+		t.Fatal("expected the agent not started")
+	}
+	files := loadState(t).Files
+	if files[".env"].State != "unlocked" || files["key.pem"].State != "locked" {
+		t.Fatalf("expected file states unchanged, got .env %s, key.pem %s", files[".env"].State, files["key.pem"].State)
+	}
+	if data, err := os.ReadFile(".env"); err != nil || string(data) != "A=2" {
+		t.Fatalf("expected the edit kept, got %q, %v", data, err)
+	}
+}
+
+func TestGuardRestoresUnlockedFiles(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available:", err)
+	}
+	setupProject(t, map[string]string{".env": "A=1", "key.pem": "secret", "id_rsa": "private"})
+	for _, args := range [][]string{
+		{"lock", ".env", "key.pem", "id_rsa"},
+		{"unlock", ".env"},
+		{"unlock", "--mode", "copy", "key.pem"},
+	} {
+		if err := run(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The agent sees only placeholders
+	if err := run("guard", "--", "sh", "-c", "grep -q A=1 .env && exit 9; grep -q secret key.pem && exit 9; exit 0"); err != nil {
+		t.Fatalf("guard failed: %v", err)
+	}
+
+	files := loadState(t).Files
+	for relPath, state := range map[string]string{".env": "unlocked", "key.pem": "unlocked-copy", "id_rsa": "locked"} {
+		if files[relPath].State != state {
+			t.Fatalf("expected %s %s again, got %s", relPath, state, files[relPath].State)
+		}
+	}
+	for relPath, content := range map[string]string{".env": "A=1", "key.pem": "secret"} {
+		if data, err := os.ReadFile(relPath); err != nil || string(data) != content {
+			t.Fatalf("expected %s readable again, got %q, %v", relPath, data, err)
+		}
+	}
+}
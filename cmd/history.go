@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func historyCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "history",
+		Usage:     "Show recorded vault revisions of a file",
+		ArgsUsage: "<path>",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() != 1 {
+				return fmt.Errorf("expected exactly one file")
+			}
+
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+
+			// No manifest lock — read-only command
+			manifest, err := project.LoadManifest()
+			if err != nil {
+				return err
+			}
+
+			if err := unsealForNames(vault, manifest); err != nil {
+				return err
+			}
+			relPath, err := core.ManagedPath(project, vault, manifest, cmd.Args().First())
+			if err != nil {
+				return err
+			}
+			entry, ok := manifest.Files[relPath]
+			if !ok {
+				return fmt.Errorf("file not managed: %s", filepath.FromSlash(relPath))
+			}
+
+			history, err := vault.LoadHistory()
+			if err != nil {
+				return err
+			}
+			revs := history.Files[relPath]
+			if len(revs) == 0 {
+				fmt.Println("no recorded revisions")
+				return nil
+			}
+
+			// Newest first; numbers match 'ignlnk restore --version <n>'
+			fmt.Printf("   %-4s%-22s%12s  %-18s%s\n", "#", "CAPTURED", "SIZE", "HASH", "REASON")
+			for i := len(revs) - 1; i >= 0; i-- {
+				rev := revs[i]
+				marker := " "
+				if rev.Hash == entry.Hash {
+					marker = "*"
+				}
+				short := strings.TrimPrefix(rev.Hash, "sha256:")
+				if len(short) > 16 {
+					short = short[:16]
+				}
+				fmt.Printf("%s  %-4d%-22s%12d  %-18s%s\n", marker, len(revs)-i, rev.CapturedAt, rev.Size, short, rev.Reason)
+			}
+			return nil
+		},
+	}
+}
+
+func restoreCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "restore",
+		Usage:     "Restore a file's vault content to a recorded revision",
+		ArgsUsage: "<path>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:     "version",
+				Usage:    "Revision number from 'ignlnk history' (1 = newest) or hash prefix",
+				Required: true,
+			},
+			&cli.StringFlag{
+				Name:  "override",
+				Usage: "Restore despite an active session, recording this reason",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			if cmd.Args().Len() != 1 {
+				return fmt.Errorf("expected exactly one file")
+			}
+
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+
+			unlock, err := project.LockManifest()
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			manifest, err := loadManifest(project, vault)
+			if err != nil {
+				return err
+			}
+
+			relPath, err := core.ManagedPath(project, vault, manifest, cmd.Args().First())
+			if err != nil {
+				return err
+			}
+
+			// Restoring rewrites protected content, so it is gated like an unlock
+			if err := gateUnlocks(project, []string{relPath}, cmd.String("override")); err != nil {
+				return err
+			}
+
+			rev, err := core.RestoreRevision(project, vault, manifest, relPath, cmd.String("version"))
+			if err != nil {
+				return err
+			}
+
+			if err := project.SaveManifest(manifest); err != nil {
+				return fmt.Errorf("saving manifest: %w", err)
+			}
+
+			fmt.Printf("restored: %s to %s (captured %s)\n", filepath.FromSlash(relPath), rev.Hash, rev.CapturedAt)
+			return nil
+		},
+	}
+}
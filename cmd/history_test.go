@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRestoreGatedBySession(t *testing.T) {
+	setupProject(t, map[string]string{".env": "A=1"})
+	for _, args := range [][]string{{"lock", ".env"}, {"unlock", ".env"}} {
+		if err := run(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(".env", []byte("A=2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("lock", ".env"); err != nil {
+		t.Fatal(err)
+	}
+	hash := loadState(t).Files[".env"].Hash
+	if err := run("session", "start", "agent"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run("restore", "--version", "2", ".env"); err == nil {
+		t.Fatal("expected restore to be blocked by the session")
+	}
+	if got := loadState(t).Files[".env"].Hash; got != hash {
+		t.Fatal("expected the vault content unchanged")
+	}
+
+	if err := run("restore", "--version", "2", "--override", "rolling back a bad edit", ".env"); err != nil {
+		t.Fatalf("restore --override failed: %v", err)
+	}
+	if got := loadState(t).Files[".env"].Hash; got == hash {
+		t.Fatal("expected the earlier revision restored")
+	}
+}
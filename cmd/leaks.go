@@ -0,0 +1,143 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func leaksCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "leaks",
+		Usage:     "Report canary tokens (lock --canary) found in files, directories or stdin ('-', the default)",
+		ArgsUsage: "[<path>|-]...",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the leaks as JSON",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+
+			// No manifest lock — read-only command
+			manifest, err := project.LoadManifest()
+			if err != nil {
+				return err
+			}
+			tokens := core.CanaryTokens(manifest)
+			if len(tokens) == 0 {
+				fmt.Fprintln(os.Stderr, "warning: no canary tokens recorded — lock files with --canary first")
+			}
+
+			// Placeholders hold their own tokens; a directory scan skips them
+			managed := make(map[string]bool, len(manifest.Files))
+			for relPath := range manifest.Files {
+				managed[project.AbsPath(relPath)] = true
+			}
+
+			args := cmd.Args().Slice()
+			if len(args) == 0 {
+				args = []string{"-"}
+			}
+			var leaks []core.Leak
+			for _, arg := range args {
+				found, err := scanLeaks(arg, tokens, managed)
+				leaks = append(leaks, found...)
+				if err != nil {
+					return err
+				}
+			}
+
+			if cmd.Bool("json") {
+				if leaks == nil {
+					leaks = []core.Leak{}
+				}
+				data, err := json.MarshalIndent(leaks, "", "  ")
+				if err != nil {
+					return fmt.Errorf("marshaling leaks: %w", err)
+				}
+				fmt.Println(string(data))
+			} else {
+				printLeaks(leaks)
+			}
+
+			if len(leaks) > 0 {
+				return fmt.Errorf("found %d leaked canary tokens", len(leaks))
+			}
+			return nil
+		},
+	}
+}
+
+// scanLeaks scans stdin ("-"), a file, or every regular file under a directory except
+// managed files and directories and the .git and .ignlnk directories.
+func scanLeaks(arg string, tokens map[string]string, managed map[string]bool) ([]core.Leak, error) {
+	if arg == "-" {
+		return core.ScanLeaks(os.Stdin, "-", tokens)
+	}
+	info, err := os.Stat(arg)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return scanLeakFile(arg, tokens)
+	}
+	var leaks []core.Leak
+	err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != arg && (d.Name() == ".git" || d.Name() == ".ignlnk") {
+				return filepath.SkipDir
+			}
+			// A locked directory holds only its placeholder
+			if abs, err := filepath.Abs(path); err == nil && managed[abs] {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if abs, err := filepath.Abs(path); err == nil && managed[abs] {
+			return nil
+		}
+		found, err := scanLeakFile(path, tokens)
+		leaks = append(leaks, found...)
+		return err
+	})
+	return leaks, err
+}
+
+func scanLeakFile(path string, tokens map[string]string) ([]core.Leak, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return core.ScanLeaks(f, path, tokens)
+}
+
+func printLeaks(leaks []core.Leak) {
+	for _, leak := range leaks {
+		if leak.Path == "" {
+			fmt.Printf("unknown canary: %s in %s:%d\n", leak.Token, leak.Source, leak.Line)
+		} else {
+			fmt.Printf("leaked: %s in %s:%d\n", filepath.FromSlash(leak.Path), leak.Source, leak.Line)
+		}
+	}
+	if len(leaks) == 0 {
+		fmt.Println("no leaks found")
+	}
+}
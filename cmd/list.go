@@ -3,7 +3,6 @@ package cmd
 import (
 	"context"
 	"fmt"
-	"path/filepath"
 	"sort"
 
 	"github.com/urfave/cli/v3"
@@ -20,6 +19,11 @@ func listCmd() *cli.Command {
 				return err
 			}
 
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+
 			// No manifest lock — read-only command
 			manifest, err := project.LoadManifest()
 			if err != nil {
@@ -37,8 +41,15 @@ func listCmd() *cli.Command {
 			}
 			sort.Strings(keys)
 
+			if err := unsealForNames(vault, manifest); err != nil {
+				return err
+			}
+			names, err := vault.HiddenNames()
+			if err != nil {
+				return err
+			}
 			for _, relPath := range keys {
-				fmt.Println(filepath.FromSlash(relPath))
+				fmt.Println(displayPath(relPath, manifest.Files[relPath], names))
 			}
 			return nil
 		},
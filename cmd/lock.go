@@ -20,6 +20,30 @@ func lockCmd() *cli.Command {
 				Name:  "force",
 				Usage: "Allow locking files larger than 1GB",
 			},
+			&cli.BoolFlag{
+				Name:  "redact",
+				Usage: "Placeholder lists the file's keys with values redacted (.env, JSON, YAML, INI); kept for later re-locks, --redact=false turns it off",
+			},
+			&cli.BoolFlag{
+				Name:  "decoy",
+				Usage: "Like --redact, but with plausible fake values so code reading the file still runs; --decoy=false turns it off",
+			},
+			&cli.BoolFlag{
+				Name:  "regions",
+				Usage: "Lock only the regions between ignlnk:begin and ignlnk:end comment lines, leaving the rest readable (default for files with markers); --regions=false locks the whole file",
+			},
+			&cli.StringFlag{
+				Name:  "note",
+				Usage: "Note shown in the placeholder (e.g. why it is locked); kept for later re-locks, --note \"\" clears it",
+			},
+			&cli.BoolFlag{
+				Name:  "hide-name",
+				Usage: "Hide the file's name too: lock it under a generic placeholder name (IGNLNK_HIDDEN_<id>.md) and store it in the vault under that ID; 'ignlnk status' still shows the real path",
+			},
+			&cli.BoolFlag{
+				Name:  "canary",
+				Usage: "Embed a unique canary token in the placeholder, so 'ignlnk leaks' can find where it was copied; kept for later re-locks, --canary=false removes it (default: config \"canary\")",
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			args := cmd.Args().Slice()
@@ -35,6 +59,9 @@ func lockCmd() *cli.Command {
 			if err != nil {
 				return err
 			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
 
 			unlock, err := project.LockManifest()
 			if err != nil {
@@ -42,7 +69,7 @@ func lockCmd() *cli.Command {
 			}
 			defer unlock()
 
-			manifest, err := project.LoadManifest()
+			manifest, err := loadManifest(project, vault)
 			if err != nil {
 				return err
 			}
@@ -50,31 +77,71 @@ func lockCmd() *cli.Command {
 			cleanup := installSignalHandler(project, manifest)
 			defer cleanup()
 
-			force := cmd.Bool("force")
+			modes := 0
+			for _, flag := range []string{"redact", "decoy", "regions"} {
+				if cmd.Bool(flag) {
+					modes++
+				}
+			}
+			if modes > 1 {
+				return fmt.Errorf("--redact, --decoy and --regions are mutually exclusive")
+			}
 			succeeded := 0
 			failed := 0
 
 			for _, arg := range args {
-				relPath, err := project.RelPath(arg)
+				relPath, err := core.ManagedPath(project, vault, manifest, arg)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "error: %s: %v\n", arg, err)
 					failed++
 					continue
 				}
 
-				if entry, ok := manifest.Files[relPath]; ok && entry.State == "locked" {
-					fmt.Printf("already locked: %s\n", filepath.FromSlash(relPath))
+				entry, managed := manifest.Files[relPath]
+				opts, err := core.DefaultLockOptions(project, manifest, relPath)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+					failed++
+					continue
+				}
+				opts.Force = cmd.Bool("force")
+				opts.Placeholder = placeholderFlag(cmd, "redact", core.PlaceholderRedacted, opts.Placeholder)
+				opts.Placeholder = placeholderFlag(cmd, "decoy", core.PlaceholderDecoy, opts.Placeholder)
+				opts.Placeholder = placeholderFlag(cmd, "regions", core.PlaceholderRegions, opts.Placeholder)
+				if cmd.IsSet("note") {
+					opts.Note = cmd.String("note")
+				}
+				if cmd.IsSet("canary") {
+					opts.Canary = cmd.Bool("canary")
+				}
+				opts.HideName = cmd.Bool("hide-name")
+				// Edits to a regions placeholder are accepted by locking again
+				if managed && entry.State == "locked" && entry.Placeholder == opts.Placeholder &&
+					entry.Note == opts.Note && (entry.Canary != "") == opts.Canary &&
+					core.FileStatus(project, vault, entry, relPath, nil) != "edited" {
+					fmt.Printf("already locked: %s\n", managedPath(vault, relPath, entry))
 					succeeded++
 					continue
 				}
 
-				if err := core.LockFile(project, vault, manifest, relPath, force); err != nil {
+				if err := core.LockFileAs(project, vault, manifest, relPath, opts); err != nil {
 					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
 					failed++
 					continue
 				}
+				if !managed && opts.HideName {
+					if key, err := core.ManagedPath(project, vault, manifest, arg); err == nil {
+						fmt.Printf("locked (hidden as %s): %s\n", filepath.Base(key), filepath.FromSlash(relPath))
+						succeeded++
+						continue
+					}
+				}
 
-				fmt.Printf("locked: %s\n", filepath.FromSlash(relPath))
+				if entry := manifest.Files[relPath]; entry.Placeholder != "" && entry.PlaceholderHash != "" {
+					fmt.Printf("locked (%s): %s\n", entry.Placeholder, managedPath(vault, relPath, entry))
+				} else {
+					fmt.Printf("locked: %s\n", managedPath(vault, relPath, entry))
+				}
 				succeeded++
 			}
 
@@ -90,3 +157,17 @@ func lockCmd() *cli.Command {
 		},
 	}
 }
+
+// placeholderFlag applies a placeholder mode flag to the file's current mode: set turns
+// the mode on, set to false turns it off if it was on.
+func placeholderFlag(cmd *cli.Command, flag, mode, current string) string {
+	switch {
+	case !cmd.IsSet(flag):
+		return current
+	case cmd.Bool(flag):
+		return mode
+	case current == mode:
+		return ""
+	}
+	return current
+}
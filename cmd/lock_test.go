@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHiddenFileOutputShowsRealPath(t *testing.T) {
+	setupProject(t, map[string]string{"docs/targets.xlsx": "secret"})
+	realPath := filepath.FromSlash("docs/targets.xlsx")
+	if _, err := runOutput(t, "lock", "--hide-name", "docs/targets.xlsx"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, args := range [][]string{
+		{"unlock", "docs/targets.xlsx"},
+		{"lock", "docs/targets.xlsx"},
+		{"unlock-all"},
+		{"lock-all"},
+		{"forget", "docs/targets.xlsx"},
+	} {
+		out, err := runOutput(t, args...)
+		if err != nil {
+			t.Fatalf("%v failed: %v", args, err)
+		}
+		if !strings.Contains(out, realPath+" (hidden as ") {
+			t.Fatalf("expected %v to show the real path, got:\n%s", args, out)
+		}
+	}
+}
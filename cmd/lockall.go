@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"sync"
 
 	"github.com/urfave/cli/v3"
 	"github.com/user/ignlnk/internal/core"
@@ -24,6 +26,15 @@ func lockAllCmd() *cli.Command {
 				Name:  "force",
 				Usage: "Allow locking files larger than 1GB",
 			},
+			&cli.BoolFlag{
+				Name:  "atomic",
+				Usage: "Stop at the first failure and return every file already processed to its previous state",
+			},
+			&cli.IntFlag{
+				Name:  "jobs",
+				Usage: "Number of files to process in parallel",
+				Value: 1,
+			},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			project, err := core.FindProject(".")
@@ -34,6 +45,12 @@ func lockAllCmd() *cli.Command {
 			if err != nil {
 				return err
 			}
+			dryRun := cmd.Bool("dry-run")
+			if !dryRun {
+				if err := unsealVault(vault); err != nil {
+					return err
+				}
+			}
 
 			unlock, err := project.LockManifest()
 			if err != nil {
@@ -41,29 +58,26 @@ func lockAllCmd() *cli.Command {
 			}
 			defer unlock()
 
-			manifest, err := project.LoadManifest()
+			// Dry run is read-only: a pending journal is left for the next real command
+			var manifest *core.Manifest
+			if dryRun {
+				manifest, err = project.LoadManifest()
+			} else {
+				manifest, err = loadManifest(project, vault)
+			}
 			if err != nil {
 				return err
 			}
 
-			// Discover new files from .ignlnkfiles
-			var newFiles []string
-			ignlnkfilesPath := filepath.Join(project.Root, ".ignlnkfiles")
-			if _, err := os.Stat(ignlnkfilesPath); err == nil {
-				ignorer, err := ignlnkfiles.Load(ignlnkfilesPath)
-				if err != nil {
-					return fmt.Errorf("parsing .ignlnkfiles: %w", err)
-				}
-				newFiles, err = ignlnkfiles.DiscoverFiles(project.Root, ignorer, manifest)
-				if err != nil {
-					return fmt.Errorf("discovering files: %w", err)
-				}
+			newFiles, err := discoverNewFiles(project, manifest)
+			if err != nil {
+				return err
 			}
 
 			// Collect already-managed unlocked files
 			var relock []string
 			for relPath, entry := range manifest.Files {
-				if entry.State == "unlocked" {
+				if entry.Unlocked() {
 					relock = append(relock, relPath)
 				}
 			}
@@ -76,7 +90,7 @@ func lockAllCmd() *cli.Command {
 			}
 
 			// Dry run mode
-			if cmd.Bool("dry-run") {
+			if dryRun {
 				fmt.Println("files that would be locked:")
 				for _, relPath := range allFiles {
 					fmt.Printf("  %s\n", filepath.FromSlash(relPath))
@@ -87,27 +101,52 @@ func lockAllCmd() *cli.Command {
 			cleanup := installSignalHandler(project, manifest)
 			defer cleanup()
 
+			var batch *core.Batch
+			if cmd.Bool("atomic") {
+				batch = core.NewBatch(project, vault, manifest)
+				defer batch.Close()
+			}
+
+			isNew := make(map[string]bool, len(newFiles))
+			for _, relPath := range newFiles {
+				isNew[relPath] = true
+			}
+
 			force := cmd.Bool("force")
 			newCount := 0
 			relockCount := 0
 			failed := 0
+			failedPath := ""
 
-			for _, relPath := range allFiles {
-				isNew := manifest.Files[relPath] == nil
-
-				if err := core.LockFile(project, vault, manifest, relPath, force); err != nil {
-					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+			shown := managedPaths(vault, manifest, allFiles)
+			stop := make(chan struct{})
+			results := runParallel(allFiles, int(cmd.Int("jobs")), stop, func(relPath string) error {
+				if batch != nil {
+					return batch.Lock(relPath, force)
+				}
+				return core.LockFile(project, vault, manifest, relPath, force)
+			})
+			for res := range results {
+				if res.err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(res.relPath), res.err)
 					failed++
+					if batch != nil && failedPath == "" {
+						failedPath = res.relPath
+						close(stop)
+					}
 					continue
 				}
 
-				fmt.Printf("locked: %s\n", filepath.FromSlash(relPath))
-				if isNew {
+				fmt.Printf("locked: %s\n", shown[res.relPath])
+				if isNew[res.relPath] {
 					newCount++
 				} else {
 					relockCount++
 				}
 			}
+			if failedPath != "" {
+				return rollbackBatch(project, manifest, batch, failedPath)
+			}
 
 			if err := project.SaveManifest(manifest); err != nil {
 				return fmt.Errorf("saving manifest: %w", err)
@@ -128,6 +167,25 @@ func unlockAllCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "unlock-all",
 		Usage: "Unlock all managed files",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "atomic",
+				Usage: "Stop at the first failure and re-lock every file already unlocked",
+			},
+			&cli.StringFlag{
+				Name:  "mode",
+				Usage: "Unlock as \"symlink\" or \"copy\" (default: each file's or the project's default)",
+			},
+			&cli.IntFlag{
+				Name:  "jobs",
+				Usage: "Number of files to process in parallel",
+				Value: 1,
+			},
+			&cli.StringFlag{
+				Name:  "override",
+				Usage: "Unlock despite an active session, recording this reason",
+			},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			project, err := core.FindProject(".")
 			if err != nil {
@@ -137,6 +195,9 @@ func unlockAllCmd() *cli.Command {
 			if err != nil {
 				return err
 			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
 
 			unlock, err := project.LockManifest()
 			if err != nil {
@@ -144,7 +205,7 @@ func unlockAllCmd() *cli.Command {
 			}
 			defer unlock()
 
-			manifest, err := project.LoadManifest()
+			manifest, err := loadManifest(project, vault)
 			if err != nil {
 				return err
 			}
@@ -161,23 +222,51 @@ func unlockAllCmd() *cli.Command {
 				fmt.Println("nothing to unlock")
 				return nil
 			}
+			sort.Strings(toUnlock)
+
+			if err := gateUnlocks(project, toUnlock, cmd.String("override")); err != nil {
+				return err
+			}
 
 			cleanup := installSignalHandler(project, manifest)
 			defer cleanup()
 
+			var batch *core.Batch
+			if cmd.Bool("atomic") {
+				batch = core.NewBatch(project, vault, manifest)
+				defer batch.Close()
+			}
+
 			succeeded := 0
 			failed := 0
+			failedPath := ""
 
-			for _, relPath := range toUnlock {
-				if err := core.UnlockFile(project, vault, manifest, relPath); err != nil {
-					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+			shown := managedPaths(vault, manifest, toUnlock)
+			stop := make(chan struct{})
+			mode := cmd.String("mode")
+			results := runParallel(toUnlock, int(cmd.Int("jobs")), stop, func(relPath string) error {
+				if batch != nil {
+					return batch.Unlock(relPath, mode)
+				}
+				return core.UnlockFileAs(project, vault, manifest, relPath, mode, false)
+			})
+			for res := range results {
+				if res.err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(res.relPath), res.err)
 					failed++
+					if batch != nil && failedPath == "" {
+						failedPath = res.relPath
+						close(stop)
+					}
 					continue
 				}
 
-				fmt.Printf("unlocked: %s\n", filepath.FromSlash(relPath))
+				fmt.Printf("unlocked: %s\n", shown[res.relPath])
 				succeeded++
 			}
+			if failedPath != "" {
+				return rollbackBatch(project, manifest, batch, failedPath)
+			}
 
 			if err := project.SaveManifest(manifest); err != nil {
 				return fmt.Errorf("saving manifest: %w", err)
@@ -192,3 +281,93 @@ func unlockAllCmd() *cli.Command {
 		},
 	}
 }
+
+// discoverNewFiles returns unmanaged files matching .ignlnkfiles, and directories matching
+// a trailing-slash pattern to lock as a unit (none without the file).
+func discoverNewFiles(project *core.Project, manifest *core.Manifest) ([]string, error) {
+	ignlnkfilesPath := filepath.Join(project.Root, ".ignlnkfiles")
+	if _, err := os.Stat(ignlnkfilesPath); err != nil {
+		return nil, nil
+	}
+	patterns, err := ignlnkfiles.Load(ignlnkfilesPath)
+	if err != nil {
+		return nil, fmt.Errorf("parsing .ignlnkfiles: %w", err)
+	}
+	newFiles, err := ignlnkfiles.DiscoverFiles(project.Root, patterns, manifest)
+	if err != nil {
+		return nil, fmt.Errorf("discovering files: %w", err)
+	}
+	return newFiles, nil
+}
+
+// rollbackBatch undoes an --atomic batch after failedPath failed and prints what was
+// rolled back. A complete rollback leaves the saved manifest untouched; an incomplete
+// one saves the manifest so it matches what is on disk.
+func rollbackBatch(project *core.Project, manifest *core.Manifest, batch *core.Batch, failedPath string) error {
+	rolled, err := batch.Rollback()
+	for _, r := range rolled {
+		fmt.Printf("rolled back: %s (%s)\n", filepath.FromSlash(r.Path), r.State)
+	}
+	if err != nil {
+		if saveErr := project.SaveManifest(manifest); saveErr != nil {
+			fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", saveErr)
+		}
+		return fmt.Errorf("%s failed and rollback is incomplete (%d files rolled back): %w — run 'ignlnk verify'", filepath.FromSlash(failedPath), len(rolled), err)
+	}
+	return fmt.Errorf("%s failed — rolled back %d files, manifest unchanged", filepath.FromSlash(failedPath), len(rolled))
+}
+
+// jobResult is the outcome of one file operation run by runParallel.
+type jobResult struct {
+	relPath string
+	err     error
+}
+
+// managedPaths maps relPaths to their managedPath for output, read before parallel
+// workers start changing the manifest.
+func managedPaths(vault *core.Vault, manifest *core.Manifest, relPaths []string) map[string]string {
+	shown := make(map[string]string, len(relPaths))
+	for _, relPath := range relPaths {
+		shown[relPath] = managedPath(vault, relPath, manifest.Files[relPath])
+	}
+	return shown
+}
+
+// runParallel runs op for each path on up to jobs workers and delivers results in
+// completion order; the channel closes once every started op has finished. Closing
+// stop makes the workers skip paths not yet started. Core file ops serialize their
+// manifest updates, so op may call them directly.
+func runParallel(paths []string, jobs int, stop <-chan struct{}, op func(relPath string) error) <-chan jobResult {
+	if jobs < 1 {
+		jobs = 1
+	}
+	work := make(chan string)
+	results := make(chan jobResult)
+
+	go func() {
+		defer close(work)
+		for _, relPath := range paths {
+			select {
+			case work <- relPath:
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	var wg sync.WaitGroup
+	for i := 0; i < jobs; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for relPath := range work {
+				results <- jobResult{relPath: relPath, err: op(relPath)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/user/ignlnk/internal/core"
+)
+
+func TestUnlockAllOverride(t *testing.T) {
+	setupProject(t, map[string]string{".env": "A=1", "key.pem": "secret"})
+	if err := run("lock", ".env", "key.pem"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("session", "start", "agent"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := run("unlock-all"); err == nil {
+		t.Fatal("expected unlock-all to be blocked by the session")
+	}
+	for relPath, entry := range loadState(t).Files {
+		if entry.State != "locked" {
+			t.Fatalf("expected %s still locked, got %s", relPath, entry.State)
+		}
+	}
+
+	if err := run("unlock-all", "--override", "debugging the build"); err != nil {
+		t.Fatalf("unlock-all --override failed: %v", err)
+	}
+	for relPath, entry := range loadState(t).Files {
+		if entry.State != "unlocked" {
+			t.Fatalf("expected %s unlocked, got %s", relPath, entry.State)
+		}
+	}
+
+	project, err := core.FindProject(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := project.LoadSession()
+	if err != nil || session == nil {
+		t.Fatalf("expected the session still active: %v", err)
+	}
+	overrides := 0
+	for _, e := range session.Events {
+		if e.Kind == core.EventUnlockOverride && e.Detail == "debugging the build" {
+			overrides++
+		}
+	}
+	if overrides != 2 {
+		t.Fatalf("expected an override recorded per file, got %+v", session.Events)
+	}
+}
+
+func TestRunParallelReportsFailureAndFinishesOthers(t *testing.T) {
+	files := map[string]string{}
+	var paths []string
+	for _, name := range []string{"a.env", "b.env", "c.env", "d.env", "e.env", "f.env"} {
+		files[name] = "secret " + name
+		paths = append(paths, name)
+	}
+	setupProject(t, files)
+	paths = append(paths[:3], append([]string{"missing.env"}, paths[3:]...)...)
+
+	project, err := core.FindProject(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault, err := core.ResolveVault(project.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest := loadState(t)
+
+	failed := map[string]error{}
+	done := 0
+	for res := range runParallel(paths, 4, make(chan struct{}), func(relPath string) error {
+		return core.LockFile(project, vault, manifest, relPath, false)
+	}) {
+		done++
+		if res.err != nil {
+			failed[res.relPath] = res.err
+		}
+	}
+	if done != len(paths) {
+		t.Fatalf("expected a result per path, got %d of %d", done, len(paths))
+	}
+	if len(failed) != 1 || failed["missing.env"] == nil {
+		t.Fatalf("expected only missing.env to fail, got %v", failed)
+	}
+	for relPath := range files {
+		if entry := manifest.Files[relPath]; entry == nil || entry.State != "locked" {
+			t.Fatalf("expected %s locked, got %+v", relPath, entry)
+		}
+		if !core.IsPlaceholder(project.AbsPath(relPath)) {
+			t.Fatalf("expected a placeholder at %s", relPath)
+		}
+	}
+}
+
+func TestLockAllJobs(t *testing.T) {
+	files := map[string]string{"broken.env": "B=1"}
+	for _, name := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		files["secrets/"+name+".env"] = "secret " + name
+	}
+	setupProject(t, files)
+	// A managed file that can no longer be re-locked
+	for _, args := range [][]string{{"lock", "broken.env"}, {"unlock", "broken.env"}} {
+		if err := run(args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove("broken.env"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir("broken.env", 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(".ignlnkfiles", []byte("secrets/*.env\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// --atomic puts every file back once the failure is seen
+	if _, err := runOutput(t, "lock-all", "--atomic", "--jobs", "4"); err == nil {
+		t.Fatal("expected lock-all --atomic to fail")
+	}
+	manifest := loadState(t)
+	for relPath, content := range files {
+		if relPath == "broken.env" {
+			continue
+		}
+		if _, ok := manifest.Files[relPath]; ok {
+			t.Fatalf("expected %s unmanaged after the rollback", relPath)
+		}
+		if data, err := os.ReadFile(relPath); err != nil || string(data) != content {
+			t.Fatalf("expected %s intact after the rollback, got %q, %v", relPath, data, err)
+		}
+	}
+
+	// Without it, the failure is reported and every other file is locked
+	out, err := runOutput(t, "lock-all", "--jobs", "4")
+	if err == nil || !strings.Contains(err.Error(), "1 files failed") {
+		t.Fatalf("expected one failed file, got %v", err)
+	}
+	if !strings.Contains(out, "locked 8 files (8 new, 0 re-locked)") {
+		t.Fatalf("expected the other files locked, got:\n%s", out)
+	}
+	manifest = loadState(t)
+	for relPath := range files {
+		if relPath == "broken.env" {
+			continue
+		}
+		if entry := manifest.Files[relPath]; entry == nil || entry.State != "locked" || !core.IsPlaceholder(relPath) {
+			t.Fatalf("expected %s locked, got %+v", relPath, entry)
+		}
+	}
+}
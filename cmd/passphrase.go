@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/user/ignlnk/internal/core"
+	"golang.org/x/term"
+)
+
+// passphraseEnv lets scripts supply the vault passphrase without a prompt.
+const passphraseEnv = "IGNLNK_PASSPHRASE"
+
+// readPassphrase returns the vault passphrase from $IGNLNK_PASSPHRASE, or prompts on stderr
+// and reads a line from stdin, without echo if it is a terminal. With confirm, an
+// interactive prompt asks twice.
+func readPassphrase(confirm bool) (string, error) {
+	if p := os.Getenv(passphraseEnv); p != "" {
+		return p, nil
+	}
+	in := bufio.NewReader(os.Stdin)
+	fmt.Fprint(os.Stderr, "vault passphrase: ")
+	p, err := readLine(in)
+	if err != nil {
+		return "", err
+	}
+	if p == "" {
+		return "", fmt.Errorf("empty passphrase")
+	}
+	if confirm {
+		fmt.Fprint(os.Stderr, "confirm passphrase: ")
+		again, err := readLine(in)
+		if err != nil {
+			return "", err
+		}
+		if again != p {
+			return "", fmt.Errorf("passphrases do not match")
+		}
+	}
+	return p, nil
+}
+
+func readLine(in *bufio.Reader) (string, error) {
+	if fd := int(os.Stdin.Fd()); term.IsTerminal(fd) {
+		line, err := term.ReadPassword(fd)
+		fmt.Fprintln(os.Stderr)
+		if err != nil {
+			return "", fmt.Errorf("reading passphrase: %w", err)
+		}
+		return string(line), nil
+	}
+	line, err := in.ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("reading passphrase: %w", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// unsealVault prompts for the passphrase of an encrypted vault and derives its key.
+// No-op for plaintext vaults.
+func unsealVault(vault *core.Vault) error {
+	if !vault.Encrypted() {
+		return nil
+	}
+	passphrase, err := readPassphrase(false)
+	if err != nil {
+		return err
+	}
+	return vault.Unseal(passphrase)
+}
+
+// unsealForNames unseals an encrypted vault for a read-only command that shows or resolves
+// hidden files' real names, since its names file is sealed. No-op without hidden files.
+func unsealForNames(vault *core.Vault, manifest *core.Manifest) error {
+	for _, entry := range manifest.Files {
+		if entry.Hidden {
+			return unsealVault(vault)
+		}
+	}
+	return nil
+}
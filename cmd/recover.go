@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func recoverCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "recover",
+		Usage: "Finish or roll back operations interrupted by a crash (runs automatically on the next command)",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "discard",
+				Usage: "Drop the pending journal without recovering (inspect with 'ignlnk verify' afterwards)",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+
+			unlock, err := project.LockManifest()
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			if cmd.Bool("discard") {
+				if err := project.DiscardJournal(); err != nil {
+					return err
+				}
+				fmt.Println("journal discarded")
+				return nil
+			}
+
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+
+			manifest, err := project.LoadManifest()
+			if err != nil {
+				return err
+			}
+			actions, err := core.RecoverJournal(project, vault, manifest)
+			for _, action := range actions {
+				fmt.Printf("recovered: %s\n", action)
+			}
+			if err != nil {
+				return fmt.Errorf("%w — fix the cause and run 'ignlnk recover' again, or 'ignlnk recover --discard'", err)
+			}
+			if err := project.SaveManifest(manifest); err != nil {
+				return fmt.Errorf("saving manifest: %w", err)
+			}
+			if len(actions) == 0 {
+				fmt.Println("nothing to recover")
+			}
+			return nil
+		},
+	}
+}
+
+// loadManifest loads the manifest for a mutating command (manifest lock held) and first
+// recovers any operation a previous run left interrupted, saving the result.
+func loadManifest(project *core.Project, vault *core.Vault) (*core.Manifest, error) {
+	manifest, err := project.LoadManifest()
+	if err != nil {
+		return nil, err
+	}
+	actions, err := core.RecoverJournal(project, vault, manifest)
+	for _, action := range actions {
+		fmt.Fprintf(os.Stderr, "recovered: %s\n", action)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w — fix the cause and run 'ignlnk recover', or 'ignlnk recover --discard'", err)
+	}
+	if len(actions) > 0 {
+		if err := project.SaveManifest(manifest); err != nil {
+			return nil, fmt.Errorf("saving recovered manifest: %w", err)
+		}
+	}
+	return manifest, nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func relocateCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "relocate",
+		Usage: "Re-register a moved or renamed project with its vault and fix unlocked symlinks",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "uid",
+				Usage: "Vault ID from ~/.ignlnk/index.json (only needed if .ignlnk/project.json is missing)",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+
+			vault, oldRoot, err := core.RelocateProject(project.Root, cmd.String("uid"))
+			if err != nil {
+				return err
+			}
+			if oldRoot != project.Root {
+				fmt.Printf("relocated: %s -> %s\n", filepath.FromSlash(oldRoot), filepath.FromSlash(project.Root))
+			} else {
+				fmt.Println("index already up to date")
+			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+
+			unlock, err := project.LockManifest()
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			manifest, err := loadManifest(project, vault)
+			if err != nil {
+				return err
+			}
+
+			keys := make([]string, 0, len(manifest.Files))
+			for k := range manifest.Files {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+
+			relinked := 0
+			failed := 0
+			for _, relPath := range keys {
+				changed, err := core.RelinkFile(project, vault, manifest, relPath)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+					failed++
+					continue
+				}
+				if changed {
+					fmt.Printf("relinked: %s\n", filepath.FromSlash(relPath))
+					relinked++
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d symlinks relinked, %d failed", relinked, failed)
+			}
+			return nil
+		},
+	}
+}
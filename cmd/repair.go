@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func repairCmd() *cli.Command {
+	return &cli.Command{
+		Name:      "repair",
+		Usage:     "Heal corrupted vault copies from their mirror backups (all managed files if none given)",
+		ArgsUsage: "[<path>...]",
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+
+			unlock, err := project.LockManifest()
+			if err != nil {
+				return err
+			}
+			defer unlock()
+
+			manifest, err := loadManifest(project, vault)
+			if err != nil {
+				return err
+			}
+
+			var targets []string
+			failed := 0
+			if cmd.Args().Len() == 0 {
+				for relPath := range manifest.Files {
+					targets = append(targets, relPath)
+				}
+				sort.Strings(targets)
+			} else {
+				for _, arg := range cmd.Args().Slice() {
+					relPath, err := core.ManagedPath(project, vault, manifest, arg)
+					if err != nil {
+						fmt.Fprintf(os.Stderr, "error: %s: %v\n", arg, err)
+						failed++
+						continue
+					}
+					targets = append(targets, relPath)
+				}
+			}
+
+			repaired := 0
+			for _, relPath := range targets {
+				action, err := core.RepairFile(project, vault, manifest, relPath)
+				if errors.Is(err, core.ErrUncommittedEdits) {
+					fmt.Printf("skipped: %s (%v)\n", filepath.FromSlash(relPath), err)
+					continue
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+					failed++
+					continue
+				}
+				if action != "" {
+					fmt.Printf("repaired: %s (%s)\n", filepath.FromSlash(relPath), action)
+					repaired++
+				}
+			}
+
+			fmt.Printf("repaired %d files\n", repaired)
+			if failed > 0 {
+				return fmt.Errorf("%d files could not be repaired", failed)
+			}
+			return nil
+		},
+	}
+}
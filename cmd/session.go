@@ -0,0 +1,229 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func sessionCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "session",
+		Usage: "Run a persistent agent session: everything locked, unlocks blocked, activity recorded",
+		Commands: []*cli.Command{
+			{
+				Name:      "start",
+				Usage:     "Lock all files and start a session (unlock is blocked until 'session stop')",
+				ArgsUsage: "[name]",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					name := cmd.Args().First()
+					if name == "" {
+						name = "session"
+					}
+					return startSession(name)
+				},
+			},
+			{
+				Name:  "stop",
+				Usage: "End the active session and print its report",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return stopSession()
+				},
+			},
+			{
+				Name:  "status",
+				Usage: "Show the active session and what it recorded so far",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					project, err := core.FindProject(".")
+					if err != nil {
+						return err
+					}
+					// No manifest lock — read-only; the Before hook already recorded changes
+					session, err := project.LoadSession()
+					if err != nil {
+						return err
+					}
+					if session == nil {
+						fmt.Println("no active session")
+						return nil
+					}
+					printSessionReport(session)
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func startSession(name string) error {
+	project, err := core.FindProject(".")
+	if err != nil {
+		return err
+	}
+	vault, err := core.ResolveVault(project.Root)
+	if err != nil {
+		return err
+	}
+	if err := unsealVault(vault); err != nil {
+		return err
+	}
+
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if active, err := project.LoadSession(); err != nil {
+		return err
+	} else if active != nil {
+		return fmt.Errorf("session %q is already active (started %s) — run 'ignlnk session stop' first", active.Name, active.StartedAt)
+	}
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return err
+	}
+	if _, err := lockEverything(project, vault, manifest); err != nil {
+		return err
+	}
+	if _, err := project.StartSession(name); err != nil {
+		return err
+	}
+	fmt.Printf("session %q started: %d files locked, unlock blocked until 'ignlnk session stop'\n", name, len(manifest.Files))
+	return nil
+}
+
+func stopSession() error {
+	project, err := core.FindProject(".")
+	if err != nil {
+		return err
+	}
+	vault, err := core.ResolveVault(project.Root)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	session, err := project.LoadSession()
+	if err != nil {
+		return err
+	}
+	if session == nil {
+		return fmt.Errorf("no active session")
+	}
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return err
+	}
+	session.Scan(project, vault, manifest)
+	if err := project.EndSession(); err != nil {
+		return err
+	}
+	printSessionReport(session)
+	fmt.Printf("session %q stopped\n", session.Name)
+	return nil
+}
+
+// printSessionReport summarizes a session's recorded events.
+func printSessionReport(s *core.Session) {
+	fmt.Printf("session %q, started %s", s.Name, s.StartedAt)
+	if started, err := time.Parse(time.RFC3339, s.StartedAt); err == nil {
+		fmt.Printf(" (%s ago)", time.Since(started).Round(time.Second))
+	}
+	fmt.Println()
+
+	counts := make(map[string]int)
+	for _, e := range s.Events {
+		counts[e.Kind]++
+	}
+	fmt.Printf("  unlocks blocked:        %d\n", counts[core.EventUnlockBlocked])
+	fmt.Printf("  unlocks overridden:     %d\n", counts[core.EventUnlockOverride])
+	fmt.Printf("  placeholders modified:  %d (%d still modified)\n", counts[core.EventPlaceholderModified], len(s.Modified))
+	fmt.Println("  (placeholders are checked at each ignlnk command and each 'ignlnk watch' interval;")
+	fmt.Println("   a change reverted between two checks is not seen)")
+
+	for _, e := range s.Events {
+		line := fmt.Sprintf("  %s  %-21s %s", e.Time, e.Kind, filepath.FromSlash(e.Path))
+		if e.Detail != "" {
+			line += " (" + e.Detail + ")"
+		}
+		fmt.Println(line)
+	}
+}
+
+// recordSessionActivity records placeholder modifications for an active session. Run by
+// the root Before hook, so every invocation notices them, and on every 'ignlnk watch'
+// tick; takes the manifest lock only when there is something new to record.
+func recordSessionActivity(project *core.Project) {
+	session, err := project.LoadSession()
+	if err != nil || session == nil {
+		return
+	}
+	manifest, err := project.LoadManifest()
+	if err != nil {
+		return
+	}
+	vault, err := core.ResolveVault(project.Root)
+	if err != nil || session.Scan(project, vault, manifest) == 0 {
+		return
+	}
+
+	unlock, err := project.LockManifest()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: recording session activity: %v\n", err)
+		return
+	}
+	defer unlock()
+	// Re-read under the lock so concurrent invocations do not record twice
+	if session, err = project.LoadSession(); err != nil || session == nil {
+		return
+	}
+	if manifest, err = project.LoadManifest(); err != nil {
+		return
+	}
+	if session.Scan(project, vault, manifest) > 0 {
+		if err := project.SaveSession(session); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+}
+
+// sessionGate applies the active session, if any, to unlocking relPath (manifest lock
+// held): the attempt is recorded, and refused unless override gives a reason. Callers
+// save the session after their loop.
+func sessionGate(session *core.Session, relPath, override string) error {
+	if session == nil || session.AllowUnlock(relPath, override) {
+		return nil
+	}
+	return fmt.Errorf("session %q is active — unlock blocked (use --override \"<reason>\" to unlock anyway)", session.Name)
+}
+
+// gateUnlocks applies the active session, if any, to a command unlocking all of targets
+// at once (manifest lock held) and saves it. Every attempt is recorded; without an
+// override reason the whole command is refused.
+func gateUnlocks(project *core.Project, targets []string, override string) error {
+	session, err := project.LoadSession()
+	if err != nil || session == nil {
+		return err
+	}
+	var gateErr error
+	for _, relPath := range targets {
+		if err := sessionGate(session, relPath, override); err != nil && gateErr == nil {
+			gateErr = err
+		}
+	}
+	if err := project.SaveSession(session); err != nil {
+		return err
+	}
+	return gateErr
+}
@@ -10,21 +10,33 @@ import (
 )
 
 // installSignalHandler registers a SIGINT handler that saves the manifest before exit.
+// The journal is kept so an operation interrupted mid-way is recovered by the next command.
 // Returns a cleanup function to deregister the handler.
 func installSignalHandler(project *core.Project, manifest *core.Manifest) func() {
+	return notifySignals(func(os.Signal) {
+		fmt.Fprintln(os.Stderr, "\ninterrupted — saving manifest...")
+		if err := project.WriteManifest(manifest); err != nil {
+			fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", err)
+		}
+		os.Exit(1)
+	}, syscall.SIGINT, syscall.SIGTERM)
+}
+
+// notifySignals calls handle, on a single goroutine, for each of sigs received until the
+// returned cleanup function deregisters it. Received signals no longer terminate the process.
+func notifySignals(handle func(os.Signal), sigs ...os.Signal) func() {
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(ch, sigs...)
 
 	done := make(chan struct{})
 	go func() {
-		select {
-		case <-ch:
-			fmt.Fprintln(os.Stderr, "\ninterrupted — saving manifest...")
-			if err := project.SaveManifest(manifest); err != nil {
-				fmt.Fprintf(os.Stderr, "error saving manifest: %v\n", err)
+		for {
+			select {
+			case sig := <-ch:
+				handle(sig)
+			case <-done:
+				return
 			}
-			os.Exit(1)
-		case <-done:
 		}
 	}()
 
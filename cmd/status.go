@@ -3,8 +3,10 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"os"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/urfave/cli/v3"
 	"github.com/user/ignlnk/internal/core"
@@ -14,6 +16,12 @@ func statusCmd() *cli.Command {
 	return &cli.Command{
 		Name:  "status",
 		Usage: "Show managed files and their state",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "no-cache",
+				Usage: "Re-hash every unlocked file instead of trusting the stat cache",
+			},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			project, err := core.FindProject(".")
 			if err != nil {
@@ -35,6 +43,12 @@ func statusCmd() *cli.Command {
 				return nil
 			}
 
+			// The real names of hidden files are sealed in an encrypted vault
+			unsealErr := unsealForNames(vault, manifest)
+			if unsealErr != nil {
+				fmt.Fprintf(os.Stderr, "warning: hidden files are shown by placeholder name: %v\n", unsealErr)
+			}
+
 			// Sort keys for stable output
 			keys := make([]string, 0, len(manifest.Files))
 			for k := range manifest.Files {
@@ -42,12 +56,116 @@ func statusCmd() *cli.Command {
 			}
 			sort.Strings(keys)
 
+			// Stat cache: unchanged unlocked files are not re-hashed. --no-cache re-hashes
+			// everything and refreshes the cache.
+			cache := project.LoadStatCache()
+			if cmd.Bool("no-cache") {
+				cache.Clear()
+			}
+
+			statuses := make(map[string]string, len(keys))
+			var edited []string
 			for _, relPath := range keys {
 				entry := manifest.Files[relPath]
-				status := core.FileStatus(project, vault, entry, relPath)
-				fmt.Printf("%-12s%s\n", status, filepath.FromSlash(relPath))
+				statuses[relPath] = core.FileStatus(project, vault, entry, relPath, cache)
+				if statuses[relPath] == "dirty" && entry.State == "unlocked-copy" && !entry.ReadOnly {
+					edited = append(edited, relPath)
+				}
+			}
+
+			// Sync edited copies back into the vault. Encrypted vaults are not unsealed
+			// here; their copies are synced on commit or re-lock.
+			if len(edited) > 0 && !vault.Encrypted() {
+				if err := syncCopies(project, vault, edited, statuses); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: syncing unlocked copies: %v\n", err)
+				}
+			}
+
+			var names map[string]string
+			if unsealErr == nil {
+				if names, err = vault.HiddenNames(); err != nil {
+					fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+				}
+			}
+			now := time.Now()
+			for _, relPath := range keys {
+				entry := manifest.Files[relPath]
+				status := statuses[relPath]
+				fmt.Printf("%-14s%s%s\n", status, displayPath(relPath, entry, names), expiryNote(entry, now))
+
+				// Record unlocked symlink edits in history (syncing a copy records its own).
+				// Encrypted vaults are not unsealed here; their edits are recorded on re-lock.
+				if status == "dirty" && entry.State == "unlocked" && !vault.Encrypted() {
+					if err := core.CaptureRevision(project, vault, entry, relPath, "dirty"); err != nil {
+						fmt.Fprintf(os.Stderr, "warning: recording history for %s: %v\n", filepath.FromSlash(relPath), err)
+					}
+				}
+			}
+
+			cache.Prune(manifest)
+			if err := cache.Save(); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
 			}
 			return nil
 		},
 	}
 }
+
+// displayPath is relPath for output, with a trailing separator if it is a managed directory,
+// and the real path if it is hidden (names from Vault.HiddenNames).
+func displayPath(relPath string, entry *core.FileEntry, names map[string]string) string {
+	if entry.Dir {
+		return filepath.FromSlash(relPath) + string(filepath.Separator)
+	}
+	if name, ok := names[relPath]; ok && entry.Hidden {
+		return fmt.Sprintf("%s (hidden as %s)", filepath.FromSlash(name), filepath.Base(relPath))
+	}
+	return filepath.FromSlash(relPath)
+}
+
+// managedPath is displayPath for one managed file in command output, with its hidden
+// name read from the vault.
+func managedPath(vault *core.Vault, relPath string, entry *core.FileEntry) string {
+	if entry == nil {
+		return filepath.FromSlash(relPath)
+	}
+	var names map[string]string
+	if entry.Hidden {
+		if name := vault.HiddenName(relPath); name != "" {
+			names = map[string]string{relPath: name}
+		}
+	}
+	return displayPath(relPath, entry, names)
+}
+
+// syncCopies commits the edits in unlocked copies relPaths into the vault under the
+// manifest lock, updating their statuses ("synced" once stored).
+func syncCopies(project *core.Project, vault *core.Vault, relPaths []string, statuses map[string]string) error {
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	// Reload under the lock; the manifest read above may be stale
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return err
+	}
+	for _, relPath := range relPaths {
+		changed, err := core.CommitFile(project, vault, manifest, relPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+			continue
+		}
+		if changed {
+			statuses[relPath] = "synced"
+		} else {
+			statuses[relPath] = "unlocked-copy"
+		}
+	}
+	if err := project.SaveManifest(manifest); err != nil {
+		return fmt.Errorf("saving manifest: %w", err)
+	}
+	return nil
+}
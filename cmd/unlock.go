@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/urfave/cli/v3"
 	"github.com/user/ignlnk/internal/core"
@@ -13,13 +14,49 @@ import (
 func unlockCmd() *cli.Command {
 	return &cli.Command{
 		Name:      "unlock",
-		Usage:     "Unlock files (replace placeholders with symlinks)",
+		Usage:     "Unlock files (replace placeholders with symlinks or real copies)",
 		ArgsUsage: "<path>...",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "mode",
+				Usage: "Unlock as a \"symlink\" to the vault or a real \"copy\" synced back on commit/re-lock (default: file or project default, else symlink)",
+			},
+			&cli.BoolFlag{
+				Name:  "remember",
+				Usage: "Make --mode the default for these files",
+			},
+			&cli.BoolFlag{
+				Name:  "read-only",
+				Usage: "Strip write permission from the unlocked file until re-lock; its changes are never committed",
+			},
+			&cli.DurationFlag{
+				Name:  "for",
+				Usage: "Re-lock automatically after this long (e.g. 15m); enforced by the next ignlnk command or 'ignlnk watch'",
+			},
+			&cli.StringFlag{
+				Name:  "override",
+				Usage: "Unlock despite an active session, recording this reason",
+			},
+		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
 			args := cmd.Args().Slice()
 			if len(args) == 0 {
 				return fmt.Errorf("no files specified")
 			}
+			mode := cmd.String("mode")
+			remember := cmd.Bool("remember")
+			if remember && mode == "" {
+				return fmt.Errorf("--remember requires --mode")
+			}
+			readOnly := cmd.Bool("read-only")
+			expiresAt := ""
+			if cmd.IsSet("for") {
+				d := cmd.Duration("for")
+				if d <= 0 {
+					return fmt.Errorf("--for must be positive")
+				}
+				expiresAt = time.Now().Add(d).UTC().Format(time.RFC3339)
+			}
 
 			project, err := core.FindProject(".")
 			if err != nil {
@@ -29,6 +66,9 @@ func unlockCmd() *cli.Command {
 			if err != nil {
 				return err
 			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
 
 			unlock, err := project.LockManifest()
 			if err != nil {
@@ -36,7 +76,11 @@ func unlockCmd() *cli.Command {
 			}
 			defer unlock()
 
-			manifest, err := project.LoadManifest()
+			manifest, err := loadManifest(project, vault)
+			if err != nil {
+				return err
+			}
+			session, err := project.LoadSession()
 			if err != nil {
 				return err
 			}
@@ -48,32 +92,68 @@ func unlockCmd() *cli.Command {
 			failed := 0
 
 			for _, arg := range args {
-				relPath, err := project.RelPath(arg)
+				relPath, err := core.ManagedPath(project, vault, manifest, arg)
 				if err != nil {
 					fmt.Fprintf(os.Stderr, "error: %s: %v\n", arg, err)
 					failed++
 					continue
 				}
 
-				if entry, ok := manifest.Files[relPath]; ok && entry.State == "unlocked" {
-					fmt.Printf("already unlocked: %s\n", filepath.FromSlash(relPath))
+				if entry, ok := manifest.Files[relPath]; ok && entry.Unlocked() {
+					if readOnly && !entry.ReadOnly {
+						fmt.Fprintf(os.Stderr, "error: %s: already unlocked writable — lock it first to unlock read-only\n", filepath.FromSlash(relPath))
+						failed++
+						continue
+					}
+					if remember {
+						entry.UnlockMode = mode
+					}
+					if expiresAt != "" {
+						entry.ExpiresAt = expiresAt
+					}
+					fmt.Printf("already unlocked: %s\n", managedPath(vault, relPath, entry))
 					succeeded++
 					continue
 				}
 
-				if err := core.UnlockFile(project, vault, manifest, relPath); err != nil {
+				if err := sessionGate(session, relPath, cmd.String("override")); err != nil {
+					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
+					failed++
+					continue
+				}
+				if err := core.UnlockFileAs(project, vault, manifest, relPath, mode, readOnly); err != nil {
 					fmt.Fprintf(os.Stderr, "error: %s: %v\n", filepath.FromSlash(relPath), err)
 					failed++
 					continue
 				}
 
-				fmt.Printf("unlocked: %s\n", filepath.FromSlash(relPath))
+				entry := manifest.Files[relPath]
+				if remember {
+					entry.UnlockMode = mode
+				}
+				entry.ExpiresAt = expiresAt
+				switch {
+				case entry.ReadOnly:
+					fmt.Printf("unlocked (ro): %s\n", managedPath(vault, relPath, entry))
+				case entry.State == "unlocked-copy":
+					fmt.Printf("unlocked (copy): %s\n", managedPath(vault, relPath, entry))
+				default:
+					fmt.Printf("unlocked: %s\n", managedPath(vault, relPath, entry))
+				}
 				succeeded++
 			}
 
 			if err := project.SaveManifest(manifest); err != nil {
 				return fmt.Errorf("saving manifest: %w", err)
 			}
+			if session != nil {
+				if err := project.SaveSession(session); err != nil {
+					return err
+				}
+			}
+			if expiresAt != "" && succeeded > 0 {
+				fmt.Printf("re-locks after %s (enforced by the next ignlnk command, or on time by 'ignlnk watch')\n", cmd.Duration("for"))
+			}
 
 			if failed > 0 {
 				return fmt.Errorf("%d of %d files unlocked, %d failed", succeeded, len(args), failed)
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func vaultCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "vault",
+		Usage: "Manage vault storage",
+		Commands: []*cli.Command{
+			{
+				Name:  "encrypt",
+				Usage: "Encrypt vault contents at rest with a passphrase (all files must be locked)",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return migrateVault(true)
+				},
+			},
+			{
+				Name:  "decrypt",
+				Usage: "Decrypt vault contents back to plaintext (all files must be locked)",
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					return migrateVault(false)
+				},
+			},
+		},
+	}
+}
+
+// migrateVault converts the project's vault (and its backup) in place.
+func migrateVault(encrypt bool) error {
+	project, err := core.FindProject(".")
+	if err != nil {
+		return err
+	}
+	vault, err := core.ResolveVault(project.Root)
+	if err != nil {
+		return err
+	}
+
+	unlock, err := project.LockManifest()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	manifest, err := loadManifest(project, vault)
+	if err != nil {
+		return err
+	}
+
+	if !encrypt && !vault.Encrypted() {
+		fmt.Println("vault is not encrypted")
+		return nil
+	}
+
+	// A new passphrase is confirmed; an existing one is verified against the vault.
+	passphrase, err := readPassphrase(encrypt && !vault.Encrypted())
+	if err != nil {
+		return err
+	}
+
+	if encrypt {
+		n, err := core.EncryptVault(vault, manifest, passphrase)
+		if err != nil {
+			return fmt.Errorf("encrypting vault (%d files converted; re-run to resume): %w", n, err)
+		}
+		fmt.Printf("encrypted vault: %d files converted\n", n)
+		return nil
+	}
+
+	n, err := core.DecryptVault(vault, manifest, passphrase)
+	if err != nil {
+		return fmt.Errorf("decrypting vault (%d files converted; re-run to resume): %w", n, err)
+	}
+	fmt.Printf("decrypted vault: %d files converted\n", n)
+	return nil
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func verifyCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "verify",
+		Usage: "Check vault copies, backups and placeholders against the manifest",
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the report as JSON",
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+
+			// No manifest lock — read-only command
+			manifest, err := project.LoadManifest()
+			if err != nil {
+				return err
+			}
+
+			report := core.VerifyProject(project, vault, manifest)
+
+			if cmd.Bool("json") {
+				data, err := json.MarshalIndent(report, "", "  ")
+				if err != nil {
+					return fmt.Errorf("marshaling report: %w", err)
+				}
+				fmt.Println(string(data))
+			} else {
+				printVerifyReport(report)
+			}
+
+			if len(report.Issues) > 0 {
+				return fmt.Errorf("verify found %d issues", len(report.Issues))
+			}
+			return nil
+		},
+	}
+}
+
+// printVerifyReport prints issues grouped by category, then a summary line.
+func printVerifyReport(report *core.VerifyReport) {
+	for _, category := range core.IssueCategories {
+		var issues []core.Issue
+		for _, issue := range report.Issues {
+			if issue.Category == category {
+				issues = append(issues, issue)
+			}
+		}
+		if len(issues) == 0 {
+			continue
+		}
+		fmt.Printf("%s (%d):\n", category, len(issues))
+		for _, issue := range issues {
+			if issue.Detail != "" {
+				fmt.Printf("  %s: %s\n", filepath.FromSlash(issue.Path), issue.Detail)
+			} else {
+				fmt.Printf("  %s\n", filepath.FromSlash(issue.Path))
+			}
+		}
+	}
+
+	if len(report.Issues) == 0 {
+		fmt.Printf("verified %d files: no issues\n", report.Checked)
+	} else {
+		fmt.Printf("verified %d files: %d issues\n", report.Checked, len(report.Issues))
+	}
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/urfave/cli/v3"
+	"github.com/user/ignlnk/internal/core"
+)
+
+func watchCmd() *cli.Command {
+	return &cli.Command{
+		Name:  "watch",
+		Usage: "Re-lock time-limited unlocks ('unlock --for') as soon as they expire, and record placeholder changes during a session; runs until interrupted",
+		Flags: []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "interval",
+				Usage: "How often to re-read the manifest for new time-limited unlocks and check placeholders during a session",
+				Value: 5 * time.Second,
+			},
+		},
+		Action: func(ctx context.Context, cmd *cli.Command) error {
+			project, err := core.FindProject(".")
+			if err != nil {
+				return err
+			}
+			vault, err := core.ResolveVault(project.Root)
+			if err != nil {
+				return err
+			}
+			// Unsealed once, so edited working copies can be sealed on expiry
+			if err := unsealVault(vault); err != nil {
+				return err
+			}
+			interval := cmd.Duration("interval")
+			if interval <= 0 {
+				return fmt.Errorf("--interval must be positive")
+			}
+
+			// Finish the current re-lock on Ctrl+C rather than dying mid-way
+			ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+			defer stop()
+
+			fmt.Printf("watching %s for expired unlocks (Ctrl+C to stop)\n", project.Root)
+			for {
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(watchTick(project, vault, interval)):
+				}
+			}
+		},
+	}
+}
+
+// watchTick records placeholder changes for an active session and re-locks expired
+// unlocks. Returns how long to wait before the next tick: interval, or less if an
+// unlock expires sooner.
+func watchTick(project *core.Project, vault *core.Vault, interval time.Duration) time.Duration {
+	recordSessionActivity(project)
+	wait := interval
+	next, ok, err := relockExpired(project, vault)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+	} else if d := time.Until(next); ok && d > 0 && d < wait {
+		wait = d
+	}
+	return wait
+}
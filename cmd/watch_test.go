@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/user/ignlnk/internal/core"
+)
+
+func TestWatchRecordsRevertedPlaceholderEdit(t *testing.T) {
+	setupProject(t, map[string]string{".env": "A=1"})
+	if err := run("lock", ".env"); err != nil {
+		t.Fatal(err)
+	}
+	if err := run("session", "start", "agent"); err != nil {
+		t.Fatal(err)
+	}
+	project, err := core.FindProject(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	vault, err := core.ResolveVault(project.Root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	placeholder, err := os.ReadFile(".env")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Edited and reverted with no ignlnk command in between
+	if err := os.WriteFile(".env", []byte("A=guess"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if wait := watchTick(project, vault, time.Minute); wait != time.Minute {
+		t.Fatalf("expected the full interval with nothing expiring, got %s", wait)
+	}
+	if err := os.WriteFile(".env", placeholder, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	watchTick(project, vault, time.Minute)
+
+	session, err := project.LoadSession()
+	if err != nil || session == nil {
+		t.Fatalf("expected the session still active: %v", err)
+	}
+	var kinds []string
+	for _, e := range session.Events {
+		kinds = append(kinds, e.Kind)
+	}
+	if len(kinds) != 2 || kinds[0] != core.EventPlaceholderModified || kinds[1] != core.EventPlaceholderRestored {
+		t.Fatalf("expected the edit and its revert recorded, got %+v", session.Events)
+	}
+}
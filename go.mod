@@ -3,9 +3,11 @@ module github.com/user/ignlnk
 go 1.24.0
 
 require (
-	github.com/gofrs/flock v0.13.0 // indirect
-	github.com/natefinch/atomic v1.0.1 // indirect
-	github.com/sabhiram/go-gitignore v0.0.0-20210923224102-525f6e181f06 // indirect
-	github.com/urfave/cli/v3 v3.6.2 // indirect
-	golang.org/x/sys v0.37.0 // indirect
+	github.com/gofrs/flock v0.13.0
+	github.com/natefinch/atomic v1.0.1
+	github.com/sabhiram/go-gitignore v0.0.0-20210923224102-525f6e181f06
+	github.com/urfave/cli/v3 v3.6.2
+	golang.org/x/term v0.36.0
 )
+
+require golang.org/x/sys v0.37.0 // indirect
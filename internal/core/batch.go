@@ -0,0 +1,199 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Batch runs lock/unlock operations that can be undone as a unit (lock-all/unlock-all
+// --atomic). It records each file's previous manifest entry and, before a re-lock
+// rotates them, snapshots the vault copy and backup so Rollback can put them back.
+type Batch struct {
+	project  *Project
+	vault    *Vault
+	manifest *Manifest
+
+	mu   sync.Mutex // Guards done; Lock and Unlock may run on parallel workers
+	done []batchOp
+}
+
+type batchOp struct {
+	relPath  string
+	prev     *FileEntry // Entry before the operation; nil for a newly locked file
+	snapshot bool       // Vault copy and backup were snapshotted
+}
+
+// Rollback describes one file returned to its previous state.
+type Rollback struct {
+	Path  string // Manifest relative path
+	State string // State restored: "locked", "unlocked", "unlocked-copy" or "unmanaged"
+}
+
+// NewBatch starts an undoable batch against manifest.
+func NewBatch(project *Project, vault *Vault, manifest *Manifest) *Batch {
+	return &Batch{project: project, vault: vault, manifest: manifest}
+}
+
+// snapshotDir holds pre-relock copies (~/.ignlnk/vault/<uid>.rollback/).
+func (b *Batch) snapshotDir() string {
+	return filepath.Join(filepath.Dir(b.vault.Dir), b.vault.UID+".rollback")
+}
+
+func (b *Batch) snapshotPaths(relPath string) (vaultSnap, backupSnap string) {
+	dir := filepath.Join(b.snapshotDir(), filepath.FromSlash(relPath))
+	return filepath.Join(dir, "vault"), filepath.Join(dir, "backup")
+}
+
+// Lock runs LockFile and records how to undo it.
+func (b *Batch) Lock(relPath string, force bool) error {
+	entry := b.manifest.entry(relPath)
+	if entry != nil && entry.State == "locked" {
+		return nil
+	}
+	op := batchOp{relPath: relPath}
+	if entry != nil {
+		prev := *entry
+		op.prev = &prev
+		// Re-lock may seal a working copy into the vault and rotates the backup
+		vaultSnap, backupSnap := b.snapshotPaths(relPath)
+		if err := os.MkdirAll(filepath.Dir(vaultSnap), 0o700); err != nil {
+			return fmt.Errorf("creating rollback snapshot: %w", err)
+		}
+		if err := snapshotCopy(b.vault.FilePath(relPath), vaultSnap, entry.Dir); err != nil {
+			return fmt.Errorf("snapshotting vault copy: %w", err)
+		}
+		if err := snapshotCopy(b.vault.BackupPath(relPath), backupSnap, entry.Dir); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("snapshotting backup: %w", err)
+		}
+		op.snapshot = true
+	}
+	if err := LockFile(b.project, b.vault, b.manifest, relPath, force); err != nil {
+		return err
+	}
+	b.record(op)
+	return nil
+}
+
+// Unlock runs UnlockFileAs and records how to undo it.
+func (b *Batch) Unlock(relPath, mode string) error {
+	entry := b.manifest.entry(relPath)
+	if entry == nil || entry.Unlocked() {
+		return UnlockFileAs(b.project, b.vault, b.manifest, relPath, mode, false)
+	}
+	prev := *entry
+	if err := UnlockFileAs(b.project, b.vault, b.manifest, relPath, mode, false); err != nil {
+		return err
+	}
+	b.record(batchOp{relPath: relPath, prev: &prev})
+	return nil
+}
+
+func (b *Batch) record(op batchOp) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = append(b.done, op)
+}
+
+// Rollback returns every file processed by the batch to its previous state, newest first
+// (call after all workers have finished),
+// restoring manifest entries exactly (in-memory). If every step succeeds, the on-disk
+// manifest already describes the result, so the journal is discarded and the caller must
+// not save. On error, the remaining files are still attempted and the caller should save
+// the manifest, which then reflects what is actually on disk.
+func (b *Batch) Rollback() ([]Rollback, error) {
+	var rolled []Rollback
+	var errs []error
+	for i := len(b.done) - 1; i >= 0; i-- {
+		op := b.done[i]
+		state, err := b.undo(op)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", op.relPath, err))
+			continue
+		}
+		rolled = append(rolled, Rollback{Path: op.relPath, State: state})
+	}
+	b.done = nil
+	if len(errs) > 0 {
+		return rolled, errors.Join(errs...)
+	}
+	return rolled, b.project.DiscardJournal()
+}
+
+func (b *Batch) undo(op batchOp) (string, error) {
+	relPath := op.relPath
+
+	// Newly locked: put the original back and drop the vault copies
+	if op.prev == nil {
+		if err := ForgetFile(b.project, b.vault, b.manifest, relPath); err != nil {
+			return "", err
+		}
+		return "unmanaged", nil
+	}
+
+	if op.prev.State == "locked" {
+		// Unlocked by the batch: re-lock. No edits happened, so content is unchanged.
+		if err := LockFile(b.project, b.vault, b.manifest, relPath, true); err != nil {
+			return "", err
+		}
+	} else {
+		// Re-locked by the batch: unlock first, so an encrypted vault's working copy is
+		// decrypted from the sealed edits, then put back the pre-relock vault copy and backup.
+		mode := UnlockModeSymlink
+		if op.prev.State == "unlocked-copy" {
+			mode = UnlockModeCopy
+		}
+		if err := UnlockFileAs(b.project, b.vault, b.manifest, relPath, mode, op.prev.ReadOnly); err != nil {
+			return "", err
+		}
+		if op.snapshot {
+			vaultSnap, backupSnap := b.snapshotPaths(relPath)
+			restore := replaceFile
+			if op.prev.Dir {
+				restore = replaceTree
+			}
+			if err := restore(vaultSnap, b.vault.FilePath(relPath)); err != nil {
+				return "", fmt.Errorf("restoring vault copy: %w", err)
+			}
+			if fileExists(backupSnap) {
+				if err := restore(backupSnap, b.vault.BackupPath(relPath)); err != nil {
+					return "", fmt.Errorf("restoring backup: %w", err)
+				}
+			}
+			// The restored vault copy is a new file; keep a read-only unlock read-only
+			if op.prev.ReadOnly {
+				if _, err := makeReadOnly(liveCopy(b.project, b.vault, op.prev, relPath)); err != nil {
+					return "", fmt.Errorf("restoring read-only permissions: %w", err)
+				}
+			}
+		}
+	}
+	prev := *op.prev
+	b.manifest.setEntry(relPath, &prev)
+	return prev.State, nil
+}
+
+// snapshotCopy copies the vault file (or managed directory) src to snapshot dst,
+// replacing an older snapshot.
+func snapshotCopy(src, dst string, dir bool) error {
+	if !dir {
+		os.Remove(dst)
+		return copyFile(src, dst)
+	}
+	os.RemoveAll(dst)
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+	tmp, err := stageTree(src, dst)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// Close removes rollback snapshots. Call once the batch is committed or rolled back.
+func (b *Batch) Close() {
+	os.RemoveAll(b.snapshotDir())
+}
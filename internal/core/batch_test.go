@@ -0,0 +1,79 @@
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBatchRollback(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	// An unlocked file with uncommitted edits, and a new file
+	edited := "edited.txt"
+	if err := os.WriteFile(p.AbsPath(edited), []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, edited, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := UnlockFile(p, v, m, edited); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p.AbsPath(edited), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	before := *m.Files[edited]
+
+	fresh := "fresh.txt"
+	if err := os.WriteFile(p.AbsPath(fresh), []byte("new"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBatch(p, v, m)
+	defer b.Close()
+	if err := b.Lock(edited, false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+	if err := b.Lock(fresh, false); err != nil {
+		t.Fatalf("lock failed: %v", err)
+	}
+	if err := b.Lock("missing.txt", false); err == nil {
+		t.Fatal("expected lock of missing file to fail")
+	}
+
+	rolled, err := b.Rollback()
+	if err != nil {
+		t.Fatalf("Rollback failed: %v", err)
+	}
+	if len(rolled) != 2 {
+		t.Fatalf("expected 2 files rolled back, got %+v", rolled)
+	}
+
+	if _, ok := m.Files[fresh]; ok {
+		t.Fatal("expected new file to be unmanaged again")
+	}
+	if got, err := os.ReadFile(p.AbsPath(fresh)); err != nil || string(got) != "new" {
+		t.Fatalf("expected original of new file restored, got %q, %v", got, err)
+	}
+	if _, err := os.Stat(v.FilePath(fresh)); !os.IsNotExist(err) {
+		t.Fatal("expected vault copy of new file removed")
+	}
+
+	if *m.Files[edited] != before {
+		t.Fatalf("expected entry %+v restored, got %+v", before, *m.Files[edited])
+	}
+	if info, err := os.Lstat(p.AbsPath(edited)); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Fatal("expected re-locked file to be unlocked again")
+	}
+	if got, err := os.ReadFile(p.AbsPath(edited)); err != nil || string(got) != "v2" {
+		t.Fatalf("expected edits kept, got %q, %v", got, err)
+	}
+	if got, err := os.ReadFile(v.BackupPath(edited)); err != nil || string(got) != "v1" {
+		t.Fatalf("expected pre-relock backup restored, got %q, %v", got, err)
+	}
+}
@@ -0,0 +1,583 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	encryptedMagic     = "IGNLNKE1"
+	encryptedChunkSize = 64 * 1024
+	noncePrefixSize    = 7
+	defaultKDFIter     = 600000
+	keyCheckPlaintext  = "ignlnk-key-check"
+)
+
+// VaultEncryption describes how an encrypted vault's key is derived.
+// Stored in the central index next to the project entry; contains no secrets.
+type VaultEncryption struct {
+	KDF        string `json:"kdf"`        // "pbkdf2-sha256"
+	Salt       string `json:"salt"`       // base64
+	Iterations int    `json:"iterations"` // PBKDF2 rounds
+	Check      string `json:"check"`      // base64 AES-GCM seal of a known value, verifies the passphrase
+}
+
+// NewVaultEncryption generates fresh key-derivation parameters for passphrase.
+func NewVaultEncryption(passphrase string) (*VaultEncryption, error) {
+	return newVaultEncryption(passphrase, defaultKDFIter)
+}
+
+func newVaultEncryption(passphrase string, iterations int) (*VaultEncryption, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase must not be empty")
+	}
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("generating salt: %w", err)
+	}
+	enc := &VaultEncryption{
+		KDF:        "pbkdf2-sha256",
+		Salt:       base64.StdEncoding.EncodeToString(salt),
+		Iterations: iterations,
+	}
+	key, err := enc.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	check := aead.Seal(nil, make([]byte, aead.NonceSize()), []byte(keyCheckPlaintext), nil)
+	enc.Check = base64.StdEncoding.EncodeToString(check)
+	return enc, nil
+}
+
+// deriveKey runs PBKDF2 over passphrase with the stored salt and iteration count.
+func (e *VaultEncryption) deriveKey(passphrase string) ([]byte, error) {
+	if e.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("unsupported key derivation %q", e.KDF)
+	}
+	salt, err := base64.StdEncoding.DecodeString(e.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("decoding salt: %w", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, e.Iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+	return key, nil
+}
+
+// Encrypted reports whether the vault stores its files as ciphertext.
+func (v *Vault) Encrypted() bool {
+	return v.Encryption != nil
+}
+
+// Unseal derives the vault key from passphrase and verifies it against the stored check value.
+func (v *Vault) Unseal(passphrase string) error {
+	if v.Encryption == nil {
+		return nil
+	}
+	key, err := v.Encryption.deriveKey(passphrase)
+	if err != nil {
+		return err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	check, err := base64.StdEncoding.DecodeString(v.Encryption.Check)
+	if err != nil {
+		return fmt.Errorf("decoding key check: %w", err)
+	}
+	if _, err := aead.Open(nil, make([]byte, aead.NonceSize()), check, nil); err != nil {
+		return fmt.Errorf("wrong passphrase for vault %s", v.UID)
+	}
+	v.key = key
+	return nil
+}
+
+// requireKey returns an error if the vault is encrypted but not yet unsealed.
+func (v *Vault) requireKey() error {
+	if v.Encrypted() && v.key == nil {
+		return fmt.Errorf("vault is encrypted — passphrase required")
+	}
+	return nil
+}
+
+// PlainDir returns the private directory holding decrypted working copies of an
+// encrypted vault's unlocked files: $XDG_RUNTIME_DIR/ignlnk/<uid>/ (per login session,
+// private to the user, usually tmpfs). "" if XDG_RUNTIME_DIR is unset: plaintext is
+// never written to a persistent location (see requirePlainDir).
+func (v *Vault) PlainDir() string {
+	if runtimeDir := os.Getenv("XDG_RUNTIME_DIR"); runtimeDir != "" {
+		return filepath.Join(runtimeDir, "ignlnk", v.UID)
+	}
+	return ""
+}
+
+// requirePlainDir returns an error if the vault is encrypted and there is no runtime
+// directory for decrypted working copies. Operations that create, use or seal a working
+// copy check it first.
+func (v *Vault) requirePlainDir() error {
+	if v.Encrypted() && v.PlainDir() == "" {
+		return fmt.Errorf("XDG_RUNTIME_DIR is not set — an encrypted vault's files unlock as symlinks only into that private runtime directory (use --mode copy, or set it)")
+	}
+	return nil
+}
+
+// WorkPath returns the path an unlocked symlink points at: the vault file itself for
+// plaintext vaults, or the decrypted working copy for encrypted vaults ("" without a
+// runtime directory).
+func (v *Vault) WorkPath(relPath string) string {
+	if !v.Encrypted() {
+		return v.FilePath(relPath)
+	}
+	if v.PlainDir() == "" {
+		return ""
+	}
+	return filepath.Join(v.PlainDir(), filepath.FromSlash(relPath))
+}
+
+// storeFile copies plaintext src into the vault at dst, encrypting if the vault is encrypted.
+func (v *Vault) storeFile(src, dst string) error {
+	_, err := v.storeFileHash(src, dst)
+	return err
+}
+
+// storeFileHash is storeFile that also returns the plaintext hash of src, computed
+// while copying so src is read only once.
+func (v *Vault) storeFileHash(src, dst string) (string, error) {
+	if v.Encrypted() {
+		if err := v.requireKey(); err != nil {
+			return "", err
+		}
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+
+	h := sha256.New()
+	r := io.TeeReader(in, h)
+	if v.Encrypted() {
+		err = writeEncrypted(dst, r, v.key)
+	} else {
+		err = writeFile(dst, r)
+	}
+	if err != nil {
+		return "", err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// restoreFile writes the plaintext of vault file src to dst, decrypting if needed.
+func (v *Vault) restoreFile(src, dst string) error {
+	if !v.isSealedFile(src) {
+		return copyFile(src, dst)
+	}
+	if err := v.requireKey(); err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if err := decryptStream(out, in, v.key); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
+
+// readStored returns the plaintext of vault file path, decrypting if needed.
+func (v *Vault) readStored(path string) ([]byte, error) {
+	if !v.isSealedFile(path) {
+		return os.ReadFile(path)
+	}
+	if err := v.requireKey(); err != nil {
+		return nil, err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	var out bytes.Buffer
+	if err := decryptStream(&out, in, v.key); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// hashStored returns the plaintext hash of a vault file, decrypting if needed.
+func (v *Vault) hashStored(path string) (string, error) {
+	hash, _, err := v.statStored(path)
+	return hash, err
+}
+
+// statStored returns the plaintext hash and size of a vault file, decrypting if needed.
+func (v *Vault) statStored(path string) (string, int64, error) {
+	if !v.isSealedFile(path) {
+		info, err := os.Stat(path)
+		if err != nil {
+			return "", 0, fmt.Errorf("stat for hash: %w", err)
+		}
+		hash, err := HashFile(path)
+		return hash, info.Size(), err
+	}
+	if err := v.requireKey(); err != nil {
+		return "", 0, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, fmt.Errorf("opening file for hash: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	cw := &countingWriter{w: h}
+	if err := decryptStream(cw, f, v.key); err != nil {
+		return "", 0, fmt.Errorf("decrypting for hash: %w", err)
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), cw.n, nil
+}
+
+// countingWriter counts bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// isSealedFile reports whether path is ciphertext written by an encrypted vault.
+// Plaintext files are tolerated so that an interrupted migration can be resumed.
+func (v *Vault) isSealedFile(path string) bool {
+	return v.Encrypted() && hasEncryptedMagic(path)
+}
+
+// EncryptVault converts every file in the vault, its backup, history and hidden names to ciphertext.
+// All managed files must be locked. Re-running with the same passphrase resumes
+// an interrupted migration. Returns the number of files converted.
+func EncryptVault(vault *Vault, manifest *Manifest, passphrase string) (int, error) {
+	if err := requireAllLocked(manifest); err != nil {
+		return 0, err
+	}
+	for relPath, entry := range manifest.Files {
+		if entry.Dir {
+			return 0, fmt.Errorf("%s is a managed directory, which only an unencrypted vault can hold — forget it before encrypting", filepath.FromSlash(relPath))
+		}
+	}
+	if vault.Encrypted() {
+		if err := vault.Unseal(passphrase); err != nil {
+			return 0, err
+		}
+	} else {
+		enc, err := NewVaultEncryption(passphrase)
+		if err != nil {
+			return 0, err
+		}
+		// Record parameters first: a crash mid-migration leaves a mixed vault
+		// that reads correctly and can be finished by re-running.
+		if err := SetVaultEncryption(vault.UID, enc); err != nil {
+			return 0, err
+		}
+		vault.Encryption = enc
+		if err := vault.Unseal(passphrase); err != nil {
+			return 0, err
+		}
+	}
+
+	converted := 0
+	err := walkVaultFiles(vault, func(path string) error {
+		if hasEncryptedMagic(path) {
+			return nil
+		}
+		in, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		err = writeEncrypted(path, in, vault.key)
+		in.Close()
+		if err != nil {
+			return fmt.Errorf("encrypting %s: %w", path, err)
+		}
+		converted++
+		return nil
+	})
+	return converted, err
+}
+
+// DecryptVault converts every file in an encrypted vault back to plaintext and
+// clears the vault's encryption parameters. All managed files must be locked.
+func DecryptVault(vault *Vault, manifest *Manifest, passphrase string) (int, error) {
+	if !vault.Encrypted() {
+		return 0, fmt.Errorf("vault is not encrypted")
+	}
+	if err := requireAllLocked(manifest); err != nil {
+		return 0, err
+	}
+	if err := vault.Unseal(passphrase); err != nil {
+		return 0, err
+	}
+
+	converted := 0
+	err := walkVaultFiles(vault, func(path string) error {
+		if !hasEncryptedMagic(path) {
+			return nil
+		}
+		tmp := path + tempSuffix
+		if err := vault.restoreFile(path, tmp); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("decrypting %s: %w", path, err)
+		}
+		if err := os.Rename(tmp, path); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("replacing %s: %w", path, err)
+		}
+		converted++
+		return nil
+	})
+	if err != nil {
+		return converted, err
+	}
+
+	if err := SetVaultEncryption(vault.UID, nil); err != nil {
+		return converted, err
+	}
+	vault.Encryption = nil
+	vault.key = nil
+	return converted, nil
+}
+
+// requireAllLocked refuses vault-wide migrations while any file is unlocked,
+// since unlocked symlinks point at the vault layout being changed.
+func requireAllLocked(manifest *Manifest) error {
+	for relPath, entry := range manifest.Files {
+		if entry.State != "locked" {
+			return fmt.Errorf("%s is %s — lock all files before migrating the vault", filepath.FromSlash(relPath), entry.State)
+		}
+	}
+	return nil
+}
+
+// walkVaultFiles calls fn for every regular file in the vault, its mirror backup and history
+// objects, and for the hidden names file.
+func walkVaultFiles(vault *Vault, fn func(path string) error) error {
+	if fileExists(vault.NamesPath()) {
+		if err := fn(vault.NamesPath()); err != nil {
+			return err
+		}
+	}
+	for _, root := range []string{vault.Dir, vault.BackupDir(), vault.objectsDir()} {
+		err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == root {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			return fn(path)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeEncrypted encrypts src into dst via a temp file and rename, so dst is never half-written.
+func writeEncrypted(dst string, src io.Reader, key []byte) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	tmp := dst + tempSuffix
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	if err := encryptStream(w, src, key); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// hasEncryptedMagic reports whether the file at path begins with the ciphertext header.
+func hasEncryptedMagic(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, len(encryptedMagic))
+	if _, err := io.ReadFull(f, buf); err != nil {
+		return false
+	}
+	return string(buf) == encryptedMagic
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("creating GCM: %w", err)
+	}
+	return aead, nil
+}
+
+// chunkNonce builds the per-chunk nonce: random prefix, big-endian counter, last-chunk flag.
+// Binding the counter and final flag into the nonce detects reordering and truncation.
+func chunkNonce(prefix []byte, counter uint32, last bool) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, prefix)
+	binary.BigEndian.PutUint32(nonce[noncePrefixSize:], counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+// encryptStream writes the ciphertext of src to dst: magic, nonce prefix, then
+// AES-GCM sealed chunks of encryptedChunkSize plaintext bytes.
+func encryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	prefix := make([]byte, noncePrefixSize)
+	if _, err := rand.Read(prefix); err != nil {
+		return fmt.Errorf("generating nonce: %w", err)
+	}
+	if _, err := dst.Write([]byte(encryptedMagic)); err != nil {
+		return err
+	}
+	if _, err := dst.Write(prefix); err != nil {
+		return err
+	}
+
+	br := bufio.NewReaderSize(src, encryptedChunkSize)
+	buf := make([]byte, encryptedChunkSize)
+	out := make([]byte, 0, encryptedChunkSize+aead.Overhead())
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(br, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last := err != nil
+		if !last {
+			if _, err := br.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		}
+		out = aead.Seal(out[:0], chunkNonce(prefix, counter, last), buf[:n], nil)
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		if counter == ^uint32(0) {
+			return fmt.Errorf("file too large to encrypt")
+		}
+	}
+}
+
+// decryptStream verifies and decrypts ciphertext from src into dst.
+func decryptStream(dst io.Writer, src io.Reader, key []byte) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	br := bufio.NewReaderSize(src, encryptedChunkSize+aead.Overhead())
+	header := make([]byte, len(encryptedMagic)+noncePrefixSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return fmt.Errorf("reading header: %w", err)
+	}
+	if !bytes.Equal(header[:len(encryptedMagic)], []byte(encryptedMagic)) {
+		return fmt.Errorf("not an encrypted vault file")
+	}
+	prefix := header[len(encryptedMagic):]
+
+	buf := make([]byte, encryptedChunkSize+aead.Overhead())
+	out := make([]byte, 0, encryptedChunkSize)
+	for counter := uint32(0); ; counter++ {
+		n, err := io.ReadFull(br, buf)
+		if err == io.EOF {
+			return fmt.Errorf("ciphertext truncated")
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return err
+		}
+		last := err != nil
+		if !last {
+			if _, err := br.Peek(1); err == io.EOF {
+				last = true
+			} else if err != nil {
+				return err
+			}
+		}
+		out, err = aead.Open(out[:0], chunkNonce(prefix, counter, last), buf[:n], nil)
+		if err != nil {
+			return errors.New("ciphertext authentication failed — vault file is corrupted or was modified")
+		}
+		if _, err := dst.Write(out); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package core
+
+import (
+	"bytes"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupEncryptedVault returns an unsealed encrypted vault with cheap KDF parameters.
+func setupEncryptedVault(t *testing.T, v *Vault) {
+	t.Helper()
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	enc, err := newVaultEncryption("correct horse", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Encryption = enc
+	if err := v.Unseal("correct horse"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEncryptStreamRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+
+	for _, size := range []int{0, 1, encryptedChunkSize - 1, encryptedChunkSize, encryptedChunkSize + 1, 3 * encryptedChunkSize} {
+		plain := make([]byte, size)
+		rand.Read(plain)
+
+		var sealed bytes.Buffer
+		if err := encryptStream(&sealed, bytes.NewReader(plain), key); err != nil {
+			t.Fatalf("size %d: encrypt: %v", size, err)
+		}
+		// Short inputs can occur in random ciphertext by chance
+		if size >= 16 && bytes.Contains(sealed.Bytes(), plain) {
+			t.Fatalf("size %d: ciphertext contains plaintext", size)
+		}
+
+		var opened bytes.Buffer
+		if err := decryptStream(&opened, bytes.NewReader(sealed.Bytes()), key); err != nil {
+			t.Fatalf("size %d: decrypt: %v", size, err)
+		}
+		if !bytes.Equal(opened.Bytes(), plain) {
+			t.Fatalf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestDecryptStreamDetectsTampering(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	plain := make([]byte, 2*encryptedChunkSize)
+	rand.Read(plain)
+
+	var sealed bytes.Buffer
+	if err := encryptStream(&sealed, bytes.NewReader(plain), key); err != nil {
+		t.Fatal(err)
+	}
+
+	flipped := append([]byte(nil), sealed.Bytes()...)
+	flipped[len(flipped)/2] ^= 0xff
+	if err := decryptStream(&bytes.Buffer{}, bytes.NewReader(flipped), key); err == nil {
+		t.Fatal("expected error for modified ciphertext")
+	}
+
+	// Drop the final chunk: the remaining chunk was not sealed as last.
+	header := len(encryptedMagic) + noncePrefixSize
+	truncated := sealed.Bytes()[:header+encryptedChunkSize+16]
+	if err := decryptStream(&bytes.Buffer{}, bytes.NewReader(truncated), key); err == nil {
+		t.Fatal("expected error for truncated ciphertext")
+	}
+}
+
+func TestVaultUnsealWrongPassphrase(t *testing.T) {
+	enc, err := newVaultEncryption("right", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := &Vault{UID: "test", Encryption: enc}
+	if err := v.Unseal("wrong"); err == nil {
+		t.Fatal("expected error for wrong passphrase")
+	}
+	if err := v.Unseal("right"); err != nil {
+		t.Fatalf("Unseal with right passphrase: %v", err)
+	}
+}
+
+func TestLockUnlockEncryptedVault(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+	setupEncryptedVault(t, v)
+
+	relPath := "config/secret.txt"
+	absPath := p.AbsPath(relPath)
+	content := []byte("API_KEY=hunter2")
+	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(absPath, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+
+	// Vault and backup hold ciphertext only
+	for _, path := range []string{v.FilePath(relPath), v.BackupPath(relPath)} {
+		stored, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(stored), encryptedMagic) || bytes.Contains(stored, content) {
+			t.Fatalf("expected ciphertext at %s", path)
+		}
+	}
+
+	if err := UnlockFile(p, v, m, relPath); err != nil {
+		t.Fatalf("UnlockFile failed: %v", err)
+	}
+	target, err := os.Readlink(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != v.WorkPath(relPath) {
+		t.Fatalf("expected symlink to working copy %s, got %s", v.WorkPath(relPath), target)
+	}
+	got, err := os.ReadFile(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("expected decrypted content %q, got %q", content, got)
+	}
+
+	// Edit through the symlink, then re-lock: edit is sealed back into the vault
+	edited := []byte("API_KEY=correct-horse")
+	if err := os.WriteFile(absPath, edited, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+	if _, err := os.Stat(v.WorkPath(relPath)); !os.IsNotExist(err) {
+		t.Fatal("expected working copy removed after re-lock")
+	}
+	if _, err := os.Stat(v.PlainDir()); !os.IsNotExist(err) {
+		t.Fatal("expected the vault's runtime directory removed once empty")
+	}
+
+	var opened bytes.Buffer
+	f, err := os.Open(v.FilePath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := decryptStream(&opened, f, v.key); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(opened.Bytes(), edited) {
+		t.Fatalf("expected vault to hold edited content %q, got %q", edited, opened.Bytes())
+	}
+}
+
+func TestLockFileEncryptedVaultRequiresKey(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+	setupEncryptedVault(t, v)
+	v.key = nil
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	content := []byte("secret")
+	if err := os.WriteFile(absPath, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LockFile(p, v, m, relPath, false); err == nil {
+		t.Fatal("expected LockFile to fail without key")
+	}
+	got, err := os.ReadFile(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Fatalf("file content changed: got %q", got)
+	}
+}
+
+func TestUnlockEncryptedVaultRequiresRuntimeDir(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+	setupEncryptedVault(t, v)
+
+	relPath := "secret.txt"
+	if err := os.WriteFile(p.AbsPath(relPath), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("XDG_RUNTIME_DIR", "")
+	if err := UnlockFile(p, v, m, relPath); err == nil {
+		t.Fatal("expected a symlink unlock to be refused without a runtime directory")
+	}
+	if status := FileStatus(p, v, m.Files[relPath], relPath, nil); status != "locked" {
+		t.Fatalf("expected the file to stay locked, got %s", status)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(v.Dir))
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".plain") {
+			t.Fatalf("expected no persistent plaintext directory, found %s", e.Name())
+		}
+	}
+
+	// A copy lands in the project, not in a working-copy directory
+	if err := UnlockFileAs(p, v, m, relPath, UnlockModeCopy, false); err != nil {
+		t.Fatalf("copy-mode unlock failed: %v", err)
+	}
+}
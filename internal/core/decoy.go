@@ -0,0 +1,176 @@
+package core
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+const decoyNotice = "Values are fake decoys; only the keys are real."
+
+// DecoyPlaceholder returns a placeholder for relPath like RedactedPlaceholder, but with
+// plausible fake values in place of the real ones, so code that reads the file at startup
+// still runs: URLs stay URLs (pointing at localhost), ports stay ports, and API-key-shaped
+// strings keep their prefix, length and character classes. Booleans and null are kept.
+// Values are derived from seed (the vault UID), relPath and the key alone, so they are
+// stable across re-locks of the same project.
+func DecoyPlaceholder(seed, relPath string, content []byte) ([]byte, error) {
+	return decoyPlaceholder(seed, relPath, plainPlaceholder(relPath), content)
+}
+
+// decoyPlaceholder is DecoyPlaceholder with the given placeholder body as the notice.
+func decoyPlaceholder(seed, relPath, body string, content []byte) ([]byte, error) {
+	return scrubbedPlaceholder(relPath, scrubbedNotice(body, decoyNotice), content, func(key, value string) string {
+		return decoyValue(newDecoyRand(seed, relPath, key), key, value)
+	})
+}
+
+// decoyRand is a deterministic byte stream: HMAC-SHA256 of the file and key in counter mode.
+type decoyRand struct {
+	mac     []byte
+	counter uint64
+	buf     []byte
+}
+
+func newDecoyRand(seed, relPath, key string) *decoyRand {
+	h := hmac.New(sha256.New, []byte(seed))
+	h.Write([]byte(relPath + "\x00" + key))
+	return &decoyRand{mac: h.Sum(nil)}
+}
+
+func (r *decoyRand) intn(n int) int {
+	if len(r.buf) < 8 {
+		var ctr [8]byte
+		binary.BigEndian.PutUint64(ctr[:], r.counter)
+		r.counter++
+		sum := sha256.Sum256(append(r.mac, ctr[:]...))
+		r.buf = sum[:]
+	}
+	v := binary.BigEndian.Uint64(r.buf[:8])
+	r.buf = r.buf[8:]
+	return int(v % uint64(n))
+}
+
+func (r *decoyRand) pick(alphabet string) byte {
+	return alphabet[r.intn(len(alphabet))]
+}
+
+const (
+	lowerChars = "abcdefghijklmnopqrstuvwxyz"
+	upperChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	digitChars = "0123456789"
+)
+
+// decoyValue returns a fake value shaped like value, or typical for key if empty.
+func decoyValue(r *decoyRand, key, value string) string {
+	name := strings.ToUpper(key[strings.LastIndexAny(key, ".")+1:])
+	switch strings.ToLower(value) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return value // Flags are not secrets, and code branches on them
+	}
+	switch {
+	case value == "":
+		return ""
+	case strings.Contains(name, "PORT") && isDigits(value):
+		return fmt.Sprint(1024 + r.intn(65535-1024))
+	case isDigits(value):
+		return decoyDigits(r, value)
+	}
+	if u, err := url.Parse(value); err == nil && u.Scheme != "" && u.Host != "" {
+		return decoyURL(r, u)
+	}
+	if at := strings.LastIndex(value, "@"); at > 0 && strings.Contains(value[at:], ".") && !strings.ContainsAny(value, " /:") {
+		return "decoy." + decoyLike(r, strings.Repeat("a", 6)) + "@example.com"
+	}
+	return decoyKeyShaped(r, value)
+}
+
+// decoyURL keeps a URL's scheme, port and path shape, with host localhost and fake
+// credentials. The query is dropped.
+func decoyURL(r *decoyRand, u *url.URL) string {
+	fake := url.URL{Scheme: u.Scheme, Host: "localhost"}
+	if port := u.Port(); port != "" {
+		fake.Host += ":" + port
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			fake.User = url.UserPassword("decoy", decoyLike(r, strings.Repeat("a", 16)))
+		} else {
+			fake.User = url.User("decoy")
+		}
+	}
+	if u.Path != "" {
+		fake.Path = decoyLike(r, u.Path)
+	}
+	return fake.String()
+}
+
+// decoyKeyShaped keeps a short leading prefix like "sk_live_", "ghp_" or "xoxb-" and
+// replaces the rest character class by character class.
+func decoyKeyShaped(r *decoyRand, value string) string {
+	prefix := ""
+	if i := strings.LastIndexAny(value, "_-"); i > 0 && i < 8 && i < len(value)-1 && isLetters(strings.NewReplacer("_", "", "-", "").Replace(value[:i])) {
+		prefix, value = value[:i+1], value[i+1:]
+	}
+	return prefix + decoyLike(r, value)
+}
+
+// decoyLike replaces each letter and digit of value with a random one of the same class
+// (hex stays hex), keeping everything else.
+func decoyLike(r *decoyRand, value string) string {
+	hex := isHex(value)
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case hex && (c >= '0' && c <= '9' || c >= 'a' && c <= 'f'):
+			b.WriteByte(r.pick("0123456789abcdef"))
+		case hex:
+			b.WriteByte(r.pick("0123456789ABCDEF"))
+		case c >= 'a' && c <= 'z':
+			b.WriteByte(r.pick(lowerChars))
+		case c >= 'A' && c <= 'Z':
+			b.WriteByte(r.pick(upperChars))
+		case c >= '0' && c <= '9':
+			b.WriteByte(r.pick(digitChars))
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// decoyDigits returns random digits as long as value, without a leading zero unless
+// value has one.
+func decoyDigits(r *decoyRand, value string) string {
+	var b strings.Builder
+	for i := range value {
+		if i == 0 && value[0] != '0' {
+			b.WriteByte(r.pick("123456789"))
+		} else {
+			b.WriteByte(r.pick(digitChars))
+		}
+	}
+	return b.String()
+}
+
+func isDigits(s string) bool {
+	return s != "" && strings.Trim(s, digitChars) == ""
+}
+
+func isLetters(s string) bool {
+	return s != "" && strings.Trim(s, lowerChars+upperChars) == ""
+}
+
+// isHex reports whether s looks like a hex string (long enough not to be a word).
+func isHex(s string) bool {
+	if len(s) < 16 {
+		return false
+	}
+	lower := strings.Trim(s, "0123456789abcdef") == ""
+	upper := strings.Trim(s, "0123456789ABCDEF") == ""
+	return lower || upper
+}
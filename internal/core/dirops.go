@@ -0,0 +1,862 @@
+package core
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/natefinch/atomic"
+)
+
+// DirPlaceholderName is the only file in a locked directory's placeholder: the directory
+// keeps its name, but none of its file names or contents stay in the project tree.
+const DirPlaceholderName = "IGNLNK_LOCKED.md"
+
+// dirOldSuffix names a directory moved aside while its replacement is renamed into place.
+const dirOldSuffix = ".ignlnk-old"
+
+// ErrDirUnsupported is returned by operations that only work on single files.
+var ErrDirUnsupported = errors.New("not supported for managed directories")
+
+// isRealDir reports whether path is a directory and not a symlink to one.
+func isRealDir(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir()
+}
+
+// managedDirOf returns the managed directory containing relPath, or "".
+func (m *Manifest) managedDirOf(relPath string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for dir := relPath; ; {
+		i := strings.LastIndex(dir, "/")
+		if i < 0 {
+			return ""
+		}
+		dir = dir[:i]
+		if e := m.Files[dir]; e != nil && e.Dir {
+			return dir
+		}
+	}
+}
+
+// managedUnder returns a managed path inside directory relPath, or "".
+func (m *Manifest) managedUnder(relPath string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var found []string
+	for p := range m.Files {
+		if relPath == "." || strings.HasPrefix(p, relPath+"/") {
+			found = append(found, p)
+		}
+	}
+	if len(found) == 0 {
+		return ""
+	}
+	sort.Strings(found)
+	return found[0]
+}
+
+// TreeHash hashes a directory tree: every subdirectory and regular file (with its
+// permissions and content) by slash-separated path, in lexical order. Returns the hash
+// in HashFile's "sha256:<hex>" form and the total size of the files. Symlinks and other
+// special files are refused, since the vault copy could not reproduce them safely.
+func TreeHash(dir string) (string, int64, error) {
+	h := sha256.New()
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == dir {
+			if !d.IsDir() {
+				return fmt.Errorf("not a directory: %s", path)
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch {
+		case d.IsDir():
+			fmt.Fprintf(h, "d %s\n", rel)
+		case d.Type().IsRegular():
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			hash, err := HashFile(path)
+			if err != nil {
+				return err
+			}
+			size += info.Size()
+			fmt.Fprintf(h, "f %o %s %s\n", info.Mode().Perm(), hash, rel)
+		default:
+			return fmt.Errorf("%s is not a regular file or directory (%s)", rel, d.Type())
+		}
+		return nil
+	})
+	if err != nil {
+		return "", 0, err
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil)), size, nil
+}
+
+// treeMatches reports whether the directory at path exists and has the given tree hash.
+func treeMatches(path, hash string) bool {
+	got, _, err := TreeHash(path)
+	return err == nil && got == hash
+}
+
+// copyTree copies directory src to dst, which must not exist, keeping permissions.
+func copyTree(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			// Writable while filling; permissions are set once the contents are copied
+			if err := os.Mkdir(target, 0o700); err != nil {
+				return err
+			}
+			return nil
+		case d.Type().IsRegular():
+			if err := copyFile(path, target); err != nil {
+				return err
+			}
+			return os.Chmod(target, info.Mode().Perm())
+		}
+		return fmt.Errorf("%s is not a regular file or directory (%s)", rel, d.Type())
+	})
+}
+
+// fixTreePerms gives the directories of copy dst the permissions of those in src.
+func fixTreePerms(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		return os.Chmod(filepath.Join(dst, rel), info.Mode().Perm()|0o700)
+	})
+}
+
+// stageTree copies directory src next to dst (at dst + tempSuffix), ready for swapIn.
+func stageTree(src, dst string) (string, error) {
+	tmp := dst + tempSuffix
+	os.RemoveAll(tmp)
+	if err := copyTree(src, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	if err := fixTreePerms(src, tmp); err != nil {
+		os.RemoveAll(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
+
+// swapIn renames staged directory tmp to dst. A directory already at dst is moved aside
+// first and removed once tmp is in place, so dst is never half-written; a symlink or file
+// at dst is removed.
+func swapIn(tmp, dst string) error {
+	info, err := os.Lstat(dst)
+	switch {
+	case err != nil:
+	case info.IsDir():
+		old := dst + dirOldSuffix
+		os.RemoveAll(old)
+		if err := os.Rename(dst, old); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			os.Rename(old, dst)
+			return err
+		}
+		return os.RemoveAll(old)
+	default:
+		if err := os.Remove(dst); err != nil {
+			return err
+		}
+	}
+	return os.Rename(tmp, dst)
+}
+
+// replaceTree replaces directory dst with a copy of directory src.
+func replaceTree(src, dst string) error {
+	tmp, err := stageTree(src, dst)
+	if err != nil {
+		return err
+	}
+	if err := swapIn(tmp, dst); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	return nil
+}
+
+// removeDirCopies deletes the vault copy and backup of directory relPath, any staged
+// copies of them, and their empty parents.
+func removeDirCopies(vault *Vault, relPath string) {
+	vaultPath := vault.FilePath(relPath)
+	os.RemoveAll(vaultPath)
+	os.RemoveAll(vaultPath + tempSuffix)
+	removeEmptyParents(filepath.Dir(vaultPath), vault.Dir)
+	backupPath := vault.BackupPath(relPath)
+	os.RemoveAll(backupPath)
+	os.RemoveAll(backupPath + tempSuffix)
+	removeEmptyParents(filepath.Dir(backupPath), vault.BackupDir())
+}
+
+// stageDirPlaceholder writes a locked directory's placeholder (a directory holding only
+// DirPlaceholderName) next to absPath, ready for swapIn.
+func stageDirPlaceholder(absPath string, placeholder []byte) (string, error) {
+	tmp := absPath + tempSuffix
+	os.RemoveAll(tmp)
+	if err := os.Mkdir(tmp, 0o755); err != nil {
+		return "", fmt.Errorf("creating placeholder directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(tmp, DirPlaceholderName), placeholder, 0o644); err != nil {
+		os.RemoveAll(tmp)
+		return "", fmt.Errorf("writing placeholder: %w", err)
+	}
+	return tmp, nil
+}
+
+// isDirPlaceholderAt reports whether absPath is exactly the placeholder of a directory
+// managed as entry: a directory holding only DirPlaceholderName, which is a placeholder
+// for relPath (see isPlaceholderAt).
+func (p *Project) isDirPlaceholderAt(absPath, relPath string, entry *FileEntry) bool {
+	if !isRealDir(absPath) {
+		return false
+	}
+	entries, err := os.ReadDir(absPath)
+	if err != nil || len(entries) != 1 || entries[0].Name() != DirPlaceholderName || !entries[0].Type().IsRegular() {
+		return false
+	}
+	info, err := entries[0].Info()
+	if err != nil {
+		return false
+	}
+	return p.isPlaceholderAt(filepath.Join(absPath, DirPlaceholderName), relPath, entry, info.Size())
+}
+
+// lockDir is LockFileAs for a directory: the whole tree moves to the vault as one entry
+// and is replaced by a directory holding only DirPlaceholderName. Directories get the
+// ordinary placeholder (with the project's template, note and canary) and no history.
+func lockDir(project *Project, vault *Vault, manifest *Manifest, entry *FileEntry, relPath string, opts LockOptions) error {
+	if opts.Placeholder != "" {
+		return fmt.Errorf("a directory only has the plain placeholder, not %s", opts.Placeholder)
+	}
+	absPath := project.AbsPath(relPath)
+
+	if entry != nil && entry.State == "locked" {
+		if entry.Note == opts.Note && (entry.Canary != "") == opts.Canary {
+			return nil
+		}
+		if !project.isDirPlaceholderAt(absPath, relPath, entry) {
+			return fmt.Errorf("refusing to replace placeholder of %s: it is not intact — run 'ignlnk verify'", relPath)
+		}
+		updated := *entry
+		updated.Note = opts.Note
+		updated.Canary = canaryFor(entry, opts.Canary)
+		placeholder, placeholderHash, err := lockPlaceholder(project, vault, relPath, &updated)
+		if err != nil {
+			return err
+		}
+		if err := project.journal("relock", relPath, "begin", entry.Hash); err != nil {
+			return err
+		}
+		if err := journalDirPlaceholder(project, "relock", relPath, entry, &updated, placeholderHash); err != nil {
+			return err
+		}
+		if err := atomic.WriteFile(filepath.Join(absPath, DirPlaceholderName), strings.NewReader(string(placeholder))); err != nil {
+			return fmt.Errorf("writing placeholder: %w", err)
+		}
+		project.journalStep("relock", relPath, "placeholder", "")
+		updated.PlaceholderHash = placeholderHash
+		manifest.setEntry(relPath, &updated)
+		return nil
+	}
+
+	vaultPath := vault.FilePath(relPath)
+
+	// Re-locking: accept edits made through the symlink, then swap it for the placeholder
+	if entry != nil {
+		info, err := os.Lstat(absPath)
+		if err != nil {
+			return fmt.Errorf("stat before re-lock: %w", err)
+		}
+		if info.Mode()&os.ModeSymlink == 0 {
+			return fmt.Errorf("refusing to re-lock %s: path is not a symlink (may contain user data). Run 'ignlnk unlock %s' first, then lock again", relPath, relPath)
+		}
+		if err := project.journal("relock", relPath, "begin", entry.Hash); err != nil {
+			return err
+		}
+		updated := *entry
+		if _, err := commitDir(vault, &updated, relPath); err != nil {
+			return err
+		}
+		project.journalStep("relock", relPath, "committed", updated.Hash)
+		updated.State = "locked"
+		updated.Note = opts.Note
+		updated.Canary = canaryFor(entry, opts.Canary)
+		updated.ExpiresAt = ""
+		updated.LockedAt = time.Now().UTC().Format(time.RFC3339)
+		placeholder, placeholderHash, err := lockPlaceholder(project, vault, relPath, &updated)
+		if err != nil {
+			return err
+		}
+		tmp, err := stageDirPlaceholder(absPath, placeholder)
+		if err != nil {
+			return err
+		}
+		if err := journalDirPlaceholder(project, "relock", relPath, entry, &updated, placeholderHash); err != nil {
+			os.RemoveAll(tmp)
+			return err
+		}
+		if err := swapIn(tmp, absPath); err != nil {
+			os.RemoveAll(tmp)
+			return fmt.Errorf("replacing symlink: %w", err)
+		}
+		project.journalStep("relock", relPath, "placeholder", "")
+		updated.PlaceholderHash = placeholderHash
+		manifest.setEntry(relPath, &updated)
+		return nil
+	}
+
+	// New directory
+	if relPath == "." {
+		return fmt.Errorf("refusing to lock the project root")
+	}
+	if vault.Encrypted() {
+		return fmt.Errorf("cannot lock directory %s: directories can only be locked in an unencrypted vault", relPath)
+	}
+	if inner := manifest.managedUnder(relPath); inner != "" {
+		return fmt.Errorf("cannot lock directory %s: it contains managed file %s — forget it first", relPath, inner)
+	}
+	if fileExists(vaultPath) || fileExists(vault.BackupPath(relPath)) {
+		return fmt.Errorf("cannot lock directory %s: the vault already holds a copy at that path — run 'ignlnk verify'", relPath)
+	}
+
+	hash, size, err := TreeHash(absPath)
+	if err != nil {
+		return fmt.Errorf("cannot lock directory %s: %w", relPath, err)
+	}
+	if size > largeSizeLimit && !opts.Force {
+		return fmt.Errorf("directory exceeds 1GB (%d MB), use --force to lock large directories", size/(1024*1024))
+	}
+	if size > largeSizeWarning {
+		fmt.Fprintf(os.Stderr, "warning: large directory (%d MB): %s\n", size/(1024*1024), filepath.FromSlash(relPath))
+	}
+
+	if err := project.journal("lock", relPath, "begin", ""); err != nil {
+		return err
+	}
+	// Until the manifest has its entry, recovery only knows a directory by this step
+	project.journalStep("lock", relPath, "dir", "")
+
+	// Copy to the vault and verify the copy
+	tmp, err := stageTree(absPath, vaultPath)
+	if err == nil {
+		err = os.Rename(tmp, vaultPath)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		removeDirCopies(vault, relPath)
+		return fmt.Errorf("copying to vault: %w", err)
+	}
+	if !treeMatches(vaultPath, hash) {
+		removeDirCopies(vault, relPath)
+		return fmt.Errorf("vault copy hash mismatch (was the directory modified while locking?) — aborting lock")
+	}
+	// Recovery needs the hash to roll forward, so this step record is required
+	if err := project.journal("lock", relPath, "stored", hash); err != nil {
+		removeDirCopies(vault, relPath)
+		return err
+	}
+
+	// Mirror backup
+	backupPath := vault.BackupPath(relPath)
+	tmp, err = stageTree(vaultPath, backupPath)
+	if err == nil {
+		err = os.Rename(tmp, backupPath)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+		removeDirCopies(vault, relPath)
+		return fmt.Errorf("copying to backup vault: %w", err)
+	}
+	project.journalStep("lock", relPath, "backed-up", hash)
+
+	// Point of no return: swap the directory for its placeholder
+	locked := &FileEntry{
+		State:    "locked",
+		LockedAt: time.Now().UTC().Format(time.RFC3339),
+		Hash:     hash,
+		Dir:      true,
+		Note:     opts.Note,
+		Canary:   canaryFor(nil, opts.Canary),
+	}
+	placeholder, placeholderHash, err := lockPlaceholder(project, vault, relPath, locked)
+	if err != nil {
+		removeDirCopies(vault, relPath)
+		return err
+	}
+	if tmp, err = stageDirPlaceholder(absPath, placeholder); err != nil {
+		removeDirCopies(vault, relPath)
+		return err
+	}
+	if err := journalDirPlaceholder(project, "lock", relPath, nil, locked, placeholderHash); err != nil {
+		os.RemoveAll(tmp)
+		return err
+	}
+	if err := swapIn(tmp, absPath); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("replacing directory with placeholder: %w", err)
+	}
+	project.journalStep("lock", relPath, "placeholder", hash)
+
+	locked.PlaceholderHash = placeholderHash
+	manifest.setEntry(relPath, locked)
+	return nil
+}
+
+// journalDirPlaceholder journals what recovery needs to recognize a directory placeholder
+// about to be written for updated: its hash, if not the default, and a new canary token.
+func journalDirPlaceholder(project *Project, op, relPath string, prev, updated *FileEntry, placeholderHash string) error {
+	if placeholderHash != "" {
+		if err := project.journal(op, relPath, placeholderTemplated, placeholderHash); err != nil {
+			return err
+		}
+	}
+	if prev == nil && updated.Canary != "" || prev != nil && updated.Canary != prev.Canary {
+		project.journalStep(op, relPath, "canary", updated.Canary)
+	}
+	return nil
+}
+
+// unlockDir is UnlockFileAs for a directory: the placeholder directory is replaced by a
+// symlink to the vault copy. Directories have no copy or read-only unlock.
+func unlockDir(project *Project, vault *Vault, manifest *Manifest, entry *FileEntry, relPath, mode string, readOnly bool) error {
+	if mode == UnlockModeCopy {
+		return fmt.Errorf("cannot unlock directory %s as a copy: directories unlock as a symlink only", relPath)
+	}
+	if readOnly {
+		return fmt.Errorf("cannot unlock directory %s read-only: directories have no read-only unlock", relPath)
+	}
+	if err := ensureSymlinkSupport(project.IgnlnkDir); err != nil {
+		return err
+	}
+
+	cfg, err := project.Config()
+	if err != nil {
+		return err
+	}
+	if cfg.AutoRepair {
+		repaired, err := repairDir(vault, entry, relPath)
+		if err != nil {
+			return fmt.Errorf("refusing to unlock %s: %w", relPath, err)
+		}
+		if repaired != "" {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", filepath.FromSlash(relPath), repaired)
+		}
+	}
+
+	vaultPath := vault.FilePath(relPath)
+	if !isRealDir(vaultPath) {
+		return fmt.Errorf("vault directory missing: %s", vaultPath)
+	}
+	absPath := project.AbsPath(relPath)
+	_, statErr := os.Lstat(absPath)
+	if statErr == nil && !project.isDirPlaceholderAt(absPath, relPath, entry) {
+		return fmt.Errorf("refusing to unlock %s: path contains user data (not the directory placeholder). Move your content elsewhere, then run 'ignlnk unlock %s' again", relPath, relPath)
+	}
+	if !treeMatches(vaultPath, entry.Hash) {
+		fmt.Fprintf(os.Stderr, "warning: vault directory hash mismatch for %s — run 'ignlnk repair %s'\n", filepath.FromSlash(relPath), filepath.FromSlash(relPath))
+	}
+
+	if err := project.journal("unlock", relPath, "begin", entry.Hash); err != nil {
+		return err
+	}
+
+	// Move the placeholder aside, so a failed symlink can put it back
+	old := absPath + dirOldSuffix
+	if statErr == nil {
+		os.RemoveAll(old)
+		if err := os.Rename(absPath, old); err != nil {
+			return fmt.Errorf("removing placeholder: %w", err)
+		}
+	}
+	if err := os.Symlink(vaultPath, absPath); err != nil {
+		if statErr == nil {
+			os.Rename(old, absPath)
+		}
+		return fmt.Errorf("creating symlink: %w", err)
+	}
+	project.journalStep("unlock", relPath, "symlink", "")
+	os.RemoveAll(old)
+
+	updated := *entry
+	updated.State = "unlocked"
+	manifest.setEntry(relPath, &updated)
+	return nil
+}
+
+// commitDir accepts edits made through an unlocked directory's symlink: the backup is
+// replaced by the vault copy and entry.Hash updated. Returns false if nothing changed.
+func commitDir(vault *Vault, entry *FileEntry, relPath string) (bool, error) {
+	vaultPath := vault.FilePath(relPath)
+	hash, _, err := TreeHash(vaultPath)
+	if err != nil {
+		return false, fmt.Errorf("hashing vault directory: %w", err)
+	}
+	if hash == entry.Hash {
+		return false, nil
+	}
+	if err := replaceTree(vaultPath, vault.BackupPath(relPath)); err != nil {
+		return false, fmt.Errorf("updating backup: %w", err)
+	}
+	entry.Hash = hash
+	return true, nil
+}
+
+// forgetDir is ForgetFile for a directory: its content is copied back from the vault in
+// place of the placeholder or symlink, and the vault copies are removed.
+func forgetDir(project *Project, vault *Vault, manifest *Manifest, entry *FileEntry, relPath string) error {
+	absPath := project.AbsPath(relPath)
+	vaultPath := vault.FilePath(relPath)
+	info, statErr := os.Lstat(absPath)
+	if statErr == nil {
+		symlink := info.Mode()&os.ModeSymlink != 0
+		if !symlink && !project.isDirPlaceholderAt(absPath, relPath, entry) {
+			return fmt.Errorf("refusing to forget %s: path contains user data (not the directory placeholder or symlink). Run 'ignlnk lock %s' first to lock, then forget", relPath, relPath)
+		}
+	}
+	if !isRealDir(vaultPath) {
+		return fmt.Errorf("vault directory missing: %s", vaultPath)
+	}
+	if err := project.journal("forget", relPath, "begin", entry.Hash); err != nil {
+		return err
+	}
+
+	tmp, err := stageTree(vaultPath, absPath)
+	if err != nil {
+		return fmt.Errorf("restoring directory from vault: %w", err)
+	}
+	if err := swapIn(tmp, absPath); err != nil {
+		os.RemoveAll(tmp)
+		return fmt.Errorf("restoring directory from vault: %w", err)
+	}
+	project.journalStep("forget", relPath, "restored", "")
+
+	removeDirCopies(vault, relPath)
+	delete(manifest.Files, relPath)
+	return nil
+}
+
+// dirStatus is FileStatus for a directory.
+func dirStatus(project *Project, vault *Vault, entry *FileEntry, relPath string) string {
+	vaultPath := vault.FilePath(relPath)
+	if !isRealDir(vaultPath) {
+		return "missing"
+	}
+	absPath := project.AbsPath(relPath)
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return "unknown"
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if !treeMatches(vaultPath, entry.Hash) {
+			return "dirty"
+		}
+		return "unlocked"
+	}
+	if project.isDirPlaceholderAt(absPath, relPath, entry) {
+		return "locked"
+	}
+	return "tampered"
+}
+
+// verifyDir is VerifyProject's check of a managed directory, reporting issues via add.
+func verifyDir(project *Project, vault *Vault, entry *FileEntry, relPath string, add func(category, relPath, detail string)) {
+	for _, c := range []struct {
+		path             string
+		missing, invalid string
+	}{
+		{vault.FilePath(relPath), IssueVaultMissing, IssueVaultMismatch},
+		{vault.BackupPath(relPath), IssueBackupMissing, IssueBackupMismatch},
+	} {
+		if !isRealDir(c.path) {
+			add(c.missing, relPath, "")
+		} else if hash, _, err := TreeHash(c.path); err != nil {
+			add(c.invalid, relPath, err.Error())
+		} else if hash != entry.Hash {
+			// The vault copy of an unlocked directory is edited in place
+			if c.invalid == IssueVaultMismatch && entry.State == "unlocked" {
+				add(IssueDirty, relPath, "run 'ignlnk commit' to accept")
+			} else {
+				add(c.invalid, relPath, "expected "+entry.Hash+", got "+hash)
+			}
+		}
+	}
+
+	absPath := project.AbsPath(relPath)
+	switch entry.State {
+	case "locked":
+		if !project.isDirPlaceholderAt(absPath, relPath, entry) {
+			add(IssuePlaceholderInvalid, relPath, "not the exact ignlnk directory placeholder")
+		}
+	case "unlocked":
+		vaultPath := vault.FilePath(relPath)
+		if info, err := os.Lstat(absPath); err != nil || info.Mode()&os.ModeSymlink == 0 {
+			add(IssueSymlinkInvalid, relPath, "not a symlink")
+		} else if target, err := os.Readlink(absPath); err != nil || target != vaultPath {
+			add(IssueSymlinkInvalid, relPath, "points at "+target+", expected "+vaultPath+" (run 'ignlnk relocate')")
+		}
+	}
+}
+
+// repairDir is repairVaultCopy for a directory. With no intact copy, both are quarantined.
+func repairDir(vault *Vault, entry *FileEntry, relPath string) (string, error) {
+	vaultPath := vault.FilePath(relPath)
+	backupPath := vault.BackupPath(relPath)
+	vaultOK := treeMatches(vaultPath, entry.Hash)
+	backupOK := treeMatches(backupPath, entry.Hash)
+
+	switch {
+	case vaultOK && backupOK:
+		return "", nil
+	case vaultOK:
+		if err := replaceTree(vaultPath, backupPath); err != nil {
+			return "", fmt.Errorf("refreshing backup: %w", err)
+		}
+		return "backup refreshed from vault", nil
+	case entry.State == "unlocked" && isRealDir(vaultPath):
+		return "", ErrUncommittedEdits
+	case backupOK:
+		if err := replaceTree(backupPath, vaultPath); err != nil {
+			return "", fmt.Errorf("restoring vault copy from backup: %w", err)
+		}
+		return "vault restored from backup", nil
+	}
+
+	dir, moved, err := quarantine(vault, relPath)
+	if err != nil {
+		return "", fmt.Errorf("no intact copy of %s (expected %s), and quarantining failed: %w", relPath, entry.Hash, err)
+	}
+	if !moved {
+		return "", fmt.Errorf("no intact copy of %s: vault copy and backup are both missing", relPath)
+	}
+	return "", fmt.Errorf("no intact copy of %s: vault copy and backup both fail verification against %s. Both were moved to %s for inspection; the directory cannot be unlocked until good content is restored", relPath, entry.Hash, dir)
+}
+
+// isDirOp reports whether a journaled op on relPath concerns a directory.
+func isDirOp(vault *Vault, op *journalOp, entry *FileEntry) bool {
+	if entry != nil {
+		return entry.Dir
+	}
+	return op.reached("dir") || isRealDir(vault.FilePath(op.path))
+}
+
+// recoverDirOp is recoverOp for a directory.
+func recoverDirOp(project *Project, vault *Vault, manifest *Manifest, op *journalOp) (string, error) {
+	relPath := op.path
+	absPath := project.AbsPath(relPath)
+	vaultPath := vault.FilePath(relPath)
+	backupPath := vault.BackupPath(relPath)
+	entry := manifest.Files[relPath]
+
+	// Staged copies are rebuilt from the vault if needed. A directory moved aside and
+	// not replaced goes back.
+	os.RemoveAll(absPath + tempSuffix)
+	old := absPath + dirOldSuffix
+	if _, err := os.Lstat(absPath); err != nil && isRealDir(old) {
+		if err := os.Rename(old, absPath); err != nil {
+			return "", err
+		}
+	}
+
+	probe := FileEntry{Dir: true}
+	if entry != nil {
+		probe = *entry
+	}
+	if _, hash := op.placeholder(); hash != "" {
+		probe.PlaceholderHash = hash
+	}
+	kind := "other"
+	if info, err := os.Lstat(absPath); err != nil {
+		kind = "missing"
+	} else if info.Mode()&os.ModeSymlink != 0 {
+		kind = "symlink"
+	} else if project.isDirPlaceholderAt(absPath, relPath, &probe) {
+		kind = "placeholder"
+	} else if info.IsDir() {
+		kind = "dir"
+	}
+
+	switch op.op {
+	case "lock":
+		if entry != nil {
+			return "", nil
+		}
+		hash := op.steps["stored"]
+		switch kind {
+		case "placeholder":
+			if hash == "" {
+				return "", fmt.Errorf("placeholder written but no stored hash was journaled")
+			}
+			if !treeMatches(vaultPath, hash) {
+				if !treeMatches(backupPath, hash) {
+					return "", fmt.Errorf("placeholder written but no vault copy matches %s", hash)
+				}
+				if err := replaceTree(backupPath, vaultPath); err != nil {
+					return "", err
+				}
+			}
+			if !treeMatches(backupPath, hash) {
+				if err := replaceTree(vaultPath, backupPath); err != nil {
+					return "", err
+				}
+			}
+			os.RemoveAll(old)
+			manifest.Files[relPath] = &FileEntry{
+				State:           "locked",
+				LockedAt:        time.Now().UTC().Format(time.RFC3339),
+				Hash:            hash,
+				Dir:             true,
+				PlaceholderHash: probe.PlaceholderHash,
+				Canary:          op.steps["canary"],
+			}
+			return "rolled forward (locked)", nil
+		case "missing":
+			if !treeMatches(vaultPath, hash) {
+				return "", fmt.Errorf("original missing and no vault copy matches %s", hash)
+			}
+			tmp, err := stageTree(vaultPath, absPath)
+			if err != nil {
+				return "", err
+			}
+			if err := os.Rename(tmp, absPath); err != nil {
+				return "", err
+			}
+		}
+		// Original still in place (or just restored): discard partial vault copies
+		removeDirCopies(vault, relPath)
+		return "rolled back (original kept)", nil
+
+	case "relock", "unlock":
+		if entry == nil {
+			return "", nil
+		}
+		// A re-lock may have refreshed the backup without saving the new hash
+		if hash, _, err := TreeHash(vaultPath); err == nil && hash != entry.Hash && treeMatches(backupPath, hash) {
+			entry.Hash = hash
+		}
+		os.RemoveAll(old)
+		switch kind {
+		case "placeholder":
+			if probe.PlaceholderHash != entry.PlaceholderHash {
+				entry.PlaceholderHash = probe.PlaceholderHash
+				if canary, ok := op.steps["canary"]; ok {
+					entry.Canary = canary
+				}
+			}
+			if entry.State == "locked" {
+				return "", nil
+			}
+			entry.State = "locked"
+			entry.LockedAt = time.Now().UTC().Format(time.RFC3339)
+			return "locked", nil
+		case "symlink":
+			if entry.State == "unlocked" {
+				return "", nil
+			}
+			entry.State = "unlocked"
+			return "unlocked", nil
+		case "missing":
+			// Lock, which is the safe state, keeping any edits made through the symlink
+			if _, err := commitDir(vault, entry, relPath); err != nil {
+				return "", err
+			}
+			entry.LockedAt = time.Now().UTC().Format(time.RFC3339)
+			placeholder, placeholderHash, err := lockPlaceholder(project, vault, relPath, entry)
+			if err != nil {
+				return "", err
+			}
+			tmp, err := stageDirPlaceholder(absPath, placeholder)
+			if err != nil {
+				return "", err
+			}
+			if err := os.Rename(tmp, absPath); err != nil {
+				os.RemoveAll(tmp)
+				return "", err
+			}
+			entry.State = "locked"
+			entry.PlaceholderHash = placeholderHash
+			return "placeholder rewritten (locked)", nil
+		}
+		return "left as is: path holds unexpected data — inspect it, then run 'ignlnk verify'", nil
+
+	case "forget":
+		if entry == nil || kind == "placeholder" || kind == "symlink" {
+			return "", nil // Nothing was removed yet; the directory stays managed
+		}
+		if kind == "missing" {
+			if !isRealDir(vaultPath) {
+				return "", fmt.Errorf("original not restored and vault copy missing")
+			}
+			tmp, err := stageTree(vaultPath, absPath)
+			if err != nil {
+				return "", err
+			}
+			if err := os.Rename(tmp, absPath); err != nil {
+				os.RemoveAll(tmp)
+				return "", err
+			}
+		}
+		os.RemoveAll(old)
+		removeDirCopies(vault, relPath)
+		delete(manifest.Files, relPath)
+		return "rolled forward (forgotten)", nil
+
+	case "commit":
+		if entry == nil {
+			return "", nil
+		}
+		if hash, _, err := TreeHash(vaultPath); err == nil && hash != entry.Hash && treeMatches(backupPath, hash) {
+			entry.Hash = hash
+			return "committed content kept", nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,217 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files (slash-separated path -> content) under root.
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestTreeHash(t *testing.T) {
+	files := map[string]string{"a.key": "a", "sub/b.key": "b"}
+	dir1, dir2 := t.TempDir(), t.TempDir()
+	writeTree(t, dir1, files)
+	writeTree(t, dir2, files)
+
+	hash1, size, err := TreeHash(dir1)
+	if err != nil {
+		t.Fatalf("TreeHash failed: %v", err)
+	}
+	if size != 2 {
+		t.Errorf("expected size 2, got %d", size)
+	}
+	if hash2, _, _ := TreeHash(dir2); hash2 != hash1 {
+		t.Fatalf("identical trees hash differently: %s, %s", hash1, hash2)
+	}
+
+	for name, change := range map[string]func(){
+		"content":  func() { writeTree(t, dir2, map[string]string{"a.key": "changed"}) },
+		"new file": func() { writeTree(t, dir2, map[string]string{"sub/c.key": ""}) },
+		"rename": func() {
+			os.Rename(filepath.Join(dir2, "a.key"), filepath.Join(dir2, "z.key"))
+		},
+		"permissions": func() { os.Chmod(filepath.Join(dir2, "a.key"), 0o600) },
+	} {
+		dir2 = t.TempDir()
+		writeTree(t, dir2, files)
+		change()
+		if hash2, _, _ := TreeHash(dir2); hash2 == hash1 {
+			t.Errorf("%s: expected hash to change", name)
+		}
+	}
+
+	if err := CheckSymlinkSupport(t.TempDir()); err == nil {
+		os.Symlink("a.key", filepath.Join(dir1, "link"))
+		if _, _, err := TreeHash(dir1); err == nil {
+			t.Error("expected a symlink in the tree to be refused")
+		}
+	}
+}
+
+func TestLockDir(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secrets"
+	absPath := p.AbsPath(relPath)
+	writeTree(t, absPath, map[string]string{"a.key": "a", "sub/b.key": "b"})
+	original, _, err := TreeHash(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	entry := m.Files[relPath]
+	if entry == nil || !entry.Dir || entry.State != "locked" || entry.Hash != original {
+		t.Fatalf("expected locked directory entry with hash %s, got %+v", original, entry)
+	}
+	if names, _ := os.ReadDir(absPath); len(names) != 1 || names[0].Name() != DirPlaceholderName {
+		t.Fatalf("expected only %s in the placeholder, got %v", DirPlaceholderName, names)
+	}
+	if status := FileStatus(p, v, entry, relPath, nil); status != "locked" {
+		t.Fatalf("expected status locked, got %s", status)
+	}
+	if !treeMatches(v.BackupPath(relPath), original) {
+		t.Fatal("expected backup to match")
+	}
+
+	// Nothing can be managed inside a managed directory
+	if err := LockFile(p, v, m, relPath+"/"+DirPlaceholderName, false); err == nil {
+		t.Fatal("expected locking a file inside a managed directory to fail")
+	}
+
+	if err := UnlockFile(p, v, m, relPath); err != nil {
+		t.Fatalf("UnlockFile failed: %v", err)
+	}
+	if target, err := os.Readlink(absPath); err != nil || target != v.FilePath(relPath) {
+		t.Fatalf("expected symlink to the vault copy, got %q, %v", target, err)
+	}
+	writeTree(t, absPath, map[string]string{"c.key": "c"})
+	if status := FileStatus(p, v, m.Files[relPath], relPath, nil); status != "dirty" {
+		t.Fatalf("expected status dirty, got %s", status)
+	}
+
+	// Re-lock accepts the edit
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+	entry = m.Files[relPath]
+	if entry.State != "locked" || entry.Hash == original || !treeMatches(v.BackupPath(relPath), entry.Hash) {
+		t.Fatalf("expected the edit committed to vault and backup, got %+v", entry)
+	}
+	if report := VerifyProject(p, v, m); len(report.Issues) != 0 {
+		t.Fatalf("expected no verify issues, got %+v", report.Issues)
+	}
+
+	if err := ForgetFile(p, v, m, relPath); err != nil {
+		t.Fatalf("ForgetFile failed: %v", err)
+	}
+	got, err := os.ReadFile(filepath.Join(absPath, "c.key"))
+	if err != nil || string(got) != "c" {
+		t.Fatalf("expected the directory restored with its edit, got %q, %v", got, err)
+	}
+	if _, ok := m.Files[relPath]; ok || fileExists(v.FilePath(relPath)) || fileExists(v.BackupPath(relPath)) {
+		t.Fatal("expected entry and vault copies removed")
+	}
+}
+
+func TestLockDirRefusesManagedContents(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	writeTree(t, p.AbsPath("secrets"), map[string]string{"a.key": "a", "b.key": "b"})
+	if err := LockFile(p, v, m, "secrets/a.key", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, "secrets", false); err == nil {
+		t.Fatal("expected locking a directory holding a managed file to fail")
+	}
+	if _, ok := m.Files["secrets"]; ok {
+		t.Fatal("expected no directory entry")
+	}
+}
+
+// crashDuringDirSwap replays lockDir up to swapping the directory for its placeholder,
+// as if the process died after moving the original aside (and, if placed, after
+// renaming the placeholder into place).
+func crashDuringDirSwap(t *testing.T, p *Project, v *Vault, relPath string, placed bool) string {
+	t.Helper()
+	absPath := p.AbsPath(relPath)
+	hash, _, err := TreeHash(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.journal("lock", relPath, "begin", ""); err != nil {
+		t.Fatal(err)
+	}
+	p.journalStep("lock", relPath, "dir", "")
+	for _, dst := range []string{v.FilePath(relPath), v.BackupPath(relPath)} {
+		if err := copyTree(absPath, dst); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.journal("lock", relPath, "stored", hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(absPath, absPath+dirOldSuffix); err != nil {
+		t.Fatal(err)
+	}
+	if placed {
+		tmp, err := stageDirPlaceholder(absPath, GeneratePlaceholder(relPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Rename(tmp, absPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return hash
+}
+
+func TestRecoverDirLock(t *testing.T) {
+	for _, placed := range []bool{false, true} {
+		p, v, m, cleanup := setupLockFileTest(t)
+		relPath := "secrets"
+		writeTree(t, p.AbsPath(relPath), map[string]string{"a.key": "a"})
+		hash := crashDuringDirSwap(t, p, v, relPath, placed)
+
+		if _, err := RecoverJournal(p, v, m); err != nil {
+			t.Fatalf("placed=%v: RecoverJournal failed: %v", placed, err)
+		}
+		if fileExists(p.AbsPath(relPath) + dirOldSuffix) {
+			t.Errorf("placed=%v: expected the moved-aside directory to be gone", placed)
+		}
+		entry, ok := m.Files[relPath]
+		if placed {
+			if !ok || !entry.Dir || entry.State != "locked" || entry.Hash != hash {
+				t.Errorf("expected rolled forward to a locked directory, got %+v", entry)
+			}
+		} else {
+			if ok || fileExists(v.FilePath(relPath)) {
+				t.Error("expected rolled back with vault copies removed")
+			}
+			if !treeMatches(p.AbsPath(relPath), hash) {
+				t.Error("expected the original directory back in place")
+			}
+		}
+		cleanup()
+	}
+}
@@ -0,0 +1,15 @@
+package core
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileIdentity returns the inode and ctime (Unix nanoseconds) of info, for the stat cache.
+func fileIdentity(info os.FileInfo) (uint64, int64) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, 0
+	}
+	return uint64(st.Ino), st.Ctim.Nano()
+}
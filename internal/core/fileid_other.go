@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package core
+
+import "os"
+
+// fileIdentity is unavailable on this platform; the stat cache relies on size and mtime.
+func fileIdentity(info os.FileInfo) (uint64, int64) {
+	return 0, 0
+}
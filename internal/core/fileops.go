@@ -19,6 +19,7 @@ const (
 	largeSizeWarning  = 100 * 1024 * 1024  // 100MB
 	largeSizeLimit    = 1024 * 1024 * 1024  // 1GB
 	progressThreshold = 10 * 1024 * 1024    // 10MB
+	tempSuffix        = ".ignlnk-tmp"
 )
 
 var (
@@ -50,51 +51,182 @@ func HashFile(path string) (string, error) {
 	return "sha256:" + hex.EncodeToString(h.Sum(nil)), nil
 }
 
-// GeneratePlaceholder returns placeholder content for a given relative path.
-func GeneratePlaceholder(relPath string) []byte {
-	content := fmt.Sprintf(`%s This file is protected by ignlnk.
-To view its contents, ask the user to run:
+// LockFile moves a file to the vault and replaces it with a placeholder (in the mode the
+// file was last locked with).
+func LockFile(project *Project, vault *Vault, manifest *Manifest, relPath string, force bool) error {
+	opts, err := DefaultLockOptions(project, manifest, relPath)
+	if err != nil {
+		return err
+	}
+	opts.Force = force
+	return LockFileAs(project, vault, manifest, relPath, opts)
+}
 
-    ignlnk unlock %s
+// DefaultLockOptions returns the options LockFile uses: those the file was last locked
+// with, or for a new file the project's canary setting, and PlaceholderRegions if the file
+// has ignlnk:begin/end markers.
+func DefaultLockOptions(project *Project, manifest *Manifest, relPath string) (LockOptions, error) {
+	if entry := manifest.entry(relPath); entry != nil {
+		return LockOptions{Placeholder: entry.Placeholder, Note: entry.Note, Canary: entry.Canary != ""}, nil
+	}
+	cfg, err := project.Config()
+	if err != nil {
+		return LockOptions{}, err
+	}
+	opts := LockOptions{Canary: cfg.Canary}
+	if fileHasRegions(project.AbsPath(relPath)) {
+		opts.Placeholder = PlaceholderRegions
+	}
+	return opts, nil
+}
 
-Do NOT attempt to modify or bypass this file.
-`, placeholderPrefix, relPath)
-	return []byte(content)
+// LockOptions are the per-file choices of LockFileAs, kept in the manifest for re-locks.
+type LockOptions struct {
+	Force bool // Lock files over 1GB
+	// Placeholder mode: "" (ordinary), PlaceholderRedacted lists a .env, JSON, YAML or
+	// INI file's keys with redacted values (see RedactedPlaceholder), PlaceholderDecoy
+	// with fake ones (see DecoyPlaceholder); other file types get the ordinary one.
+	// PlaceholderRegions only locks the marked regions of any text file (see
+	// RegionsPlaceholder).
+	Placeholder string
+	Note        string // Shown in the placeholder, via the project's template
+	// Embed a canary token in the placeholder (see ScanLeaks). A file keeps its token
+	// across re-locks.
+	Canary bool
+	// Lock a new file under a generic placeholder name (see HiddenPrefix), keeping its
+	// real name out of the project tree and the vault
+	HideName bool
 }
 
-// LockFile moves a file to the vault and replaces it with a placeholder.
-func LockFile(project *Project, vault *Vault, manifest *Manifest, relPath string, force bool) error {
+// LockFileAs is LockFile with explicit options rather than those the file was last
+// locked with. An already locked file only has its placeholder rewritten if the mode,
+// note or canary differs.
+func LockFileAs(project *Project, vault *Vault, manifest *Manifest, relPath string, opts LockOptions) error {
+	if entry := manifest.entry(relPath); entry != nil && entry.Dir || entry == nil && isRealDir(project.AbsPath(relPath)) {
+		if opts.HideName {
+			return fmt.Errorf("%s: hiding the name is %w", relPath, ErrDirUnsupported)
+		}
+		return lockDir(project, vault, manifest, entry, relPath, opts)
+	}
+	if dir := manifest.managedDirOf(relPath); dir != "" {
+		return fmt.Errorf("%s is inside managed directory %s", relPath, dir)
+	}
+	if entry := manifest.entry(relPath); opts.HideName && entry == nil {
+		return lockHidden(project, vault, manifest, relPath, opts)
+	} else if opts.HideName && !entry.Hidden {
+		return fmt.Errorf("%s is already managed under its name — forget it first to hide the name", relPath)
+	}
+	if opts.Placeholder != "" && opts.Placeholder != PlaceholderRegions && !Redactable(relPath) {
+		fmt.Fprintf(os.Stderr, "warning: %s: no %s placeholder for this file type (supported: %s), using the plain one\n", filepath.FromSlash(relPath), opts.Placeholder, redactableFormats)
+		opts.Placeholder = ""
+	}
+
 	// Idempotent: already locked = no-op
-	if entry, ok := manifest.Files[relPath]; ok && entry.State == "locked" {
-		return nil
+	entry := manifest.entry(relPath)
+	if entry != nil && entry.State == "locked" {
+		// Accept edits to a regions placeholder's readable portion
+		if entry.Placeholder == PlaceholderRegions {
+			if committed, err := commitRegionEdits(project, vault, manifest, entry, relPath); err != nil {
+				return fmt.Errorf("refusing to re-lock %s: %w", relPath, err)
+			} else if committed {
+				entry = manifest.entry(relPath)
+			}
+		}
+		if entry.Placeholder == opts.Placeholder && entry.Note == opts.Note && (entry.Canary != "") == opts.Canary {
+			return nil
+		}
+		return replacePlaceholder(project, vault, manifest, entry, relPath, opts)
 	}
 
 	absPath := project.AbsPath(relPath)
 
-	// Re-locking: if file is already managed and unlocked (symlink), swap symlink for placeholder.
-	// We verify absPath is a symlink before removing — if it's a regular file, refuse to avoid data loss.
-	if entry, ok := manifest.Files[relPath]; ok && entry.State == "unlocked" {
-		info, err := os.Lstat(absPath)
+	// Re-locking: if file is already managed and unlocked, accept any edits made while
+	// unlocked, then swap the symlink (or synced copy) for a placeholder.
+	// We verify the live path is a symlink before removing — if it's a regular file, refuse to avoid data loss.
+	if entry != nil && entry.Unlocked() {
+		linkPath := livePath(project, vault, entry, relPath)
+		info, err := os.Lstat(linkPath)
 		if err != nil {
 			return fmt.Errorf("stat before re-lock: %w", err)
 		}
-		if info.Mode()&os.ModeSymlink == 0 {
+		copyMode := entry.State == "unlocked-copy"
+		if !copyMode {
+			if err := vault.requirePlainDir(); err != nil {
+				return fmt.Errorf("refusing to re-lock %s: %w", relPath, err)
+			}
+		}
+		if copyMode && !info.Mode().IsRegular() {
+			return fmt.Errorf("refusing to re-lock %s: unlocked as a copy but path is not a regular file", relPath)
+		}
+		if !copyMode && info.Mode()&os.ModeSymlink == 0 {
 			return fmt.Errorf("refusing to re-lock %s: path is not a symlink (may contain user data). Run 'ignlnk unlock %s' first, then lock again", relPath, relPath)
 		}
-		if err := os.Remove(absPath); err != nil {
-			return fmt.Errorf("removing symlink: %w", err)
+		if err := project.journal("relock", relPath, "begin", entry.Hash); err != nil {
+			return err
+		}
+		updated := *entry
+		if entry.ReadOnly {
+			// Read-only unlock: give back write permission, and never commit its changes
+			if err := discardReadOnlyChanges(project, vault, entry, relPath); err != nil {
+				return err
+			}
+			updated.ReadOnly, updated.Perm = false, 0
+		} else if !copyMode || !project.isPlaceholderAt(absPath, relPath, entry, info.Size()) {
+			// A copy that is already the placeholder has nothing to sync
+			if changed, err := commitVaultCopy(vault, &updated, relPath, liveCopy(project, vault, entry, relPath)); err != nil {
+				return err
+			} else if changed {
+				committed := updated
+				manifest.setEntry(relPath, &committed)
+			}
+		}
+		project.journalStep("relock", relPath, "committed", updated.Hash)
+		updated.State = "locked"
+		updated.Placeholder, updated.Note = opts.Placeholder, opts.Note
+		updated.Canary = canaryFor(entry, opts.Canary)
+		updated.ExpiresAt = ""
+		updated.LockedAt = time.Now().UTC().Format(time.RFC3339)
+		placeholder, placeholderHash, err := lockPlaceholder(project, vault, relPath, &updated)
+		if err != nil {
+			return err
+		}
+		// A copy is replaced atomically by the placeholder write below
+		if !copyMode {
+			if err := os.Remove(linkPath); err != nil {
+				return fmt.Errorf("removing symlink: %w", err)
+			}
+		}
+		if placeholderHash != "" {
+			// Recovery can only recognize this placeholder by its journaled hash
+			if err := project.journal("relock", relPath, placeholderStep(opts.Placeholder), placeholderHash); err != nil {
+				return err
+			}
+		}
+		if updated.Canary != entry.Canary {
+			project.journalStep("relock", relPath, "canary", updated.Canary)
 		}
-		placeholder := GeneratePlaceholder(relPath)
 		r := strings.NewReader(string(placeholder))
 		if err := atomic.WriteFile(absPath, r); err != nil {
 			return fmt.Errorf("writing placeholder: %w", err)
 		}
-		entry.State = "locked"
+		project.journalStep("relock", relPath, "placeholder", "")
+		removeWorkCopy(vault, relPath)
+		updated.PlaceholderHash = placeholderHash
+		manifest.setEntry(relPath, &updated)
+		warnCapture(project, vault, relPath, "relock")
 		return nil
 	}
+	return lockNew(project, vault, manifest, entry, relPath, absPath, opts)
+}
+
+// lockNew locks the unmanaged file at srcPath as relPath: stores it in the vault, writes
+// relPath's placeholder and, if srcPath is elsewhere (a hidden file's real path), removes
+// the original.
+func lockNew(project *Project, vault *Vault, manifest *Manifest, entry *FileEntry, relPath, srcPath string, opts LockOptions) error {
+	absPath := project.AbsPath(relPath)
 
 	// Verify file exists and is regular
-	info, err := os.Lstat(absPath)
+	info, err := os.Lstat(srcPath)
 	if err != nil {
 		return fmt.Errorf("file not found: %w", err)
 	}
@@ -104,30 +236,31 @@ func LockFile(project *Project, vault *Vault, manifest *Manifest, relPath string
 
 	// Size checks
 	size := info.Size()
-	if size > largeSizeLimit && !force {
+	if size > largeSizeLimit && !opts.Force {
 		return fmt.Errorf("file exceeds 1GB (%d MB), use --force to lock large files", size/(1024*1024))
 	}
 	if size > largeSizeWarning {
 		fmt.Fprintf(os.Stderr, "warning: large file (%d MB): %s\n", size/(1024*1024), filepath.FromSlash(relPath))
 	}
 
-	// Hash the original file
-	hash, err := HashFile(absPath)
-	if err != nil {
+	if err := project.journal("lock", relPath, "begin", ""); err != nil {
 		return err
 	}
 
-	// Create vault parent dirs and copy to vault
+	// Create vault parent dirs and copy to vault (encrypted if the vault is), hashing the
+	// original in the same pass
 	vaultPath := vault.FilePath(relPath)
 	if err := os.MkdirAll(filepath.Dir(vaultPath), 0o755); err != nil {
 		return fmt.Errorf("creating vault directory: %w", err)
 	}
-	if err := copyFile(absPath, vaultPath); err != nil {
+	hash, err := vault.storeFileHash(srcPath, vaultPath)
+	if err != nil {
+		os.Remove(vaultPath)
 		return fmt.Errorf("copying to vault: %w", err)
 	}
 
-	// Verify vault copy hash
-	vaultHash, err := HashFile(vaultPath)
+	// Verify vault copy hash (plaintext hash, decrypting if needed)
+	vaultHash, err := vault.hashStored(vaultPath)
 	if err != nil {
 		os.Remove(vaultPath)
 		return fmt.Errorf("verifying vault copy: %w", err)
@@ -136,6 +269,11 @@ func LockFile(project *Project, vault *Vault, manifest *Manifest, relPath string
 		os.Remove(vaultPath)
 		return fmt.Errorf("vault copy hash mismatch — aborting lock")
 	}
+	// Recovery needs the hash to roll forward, so this step record is required
+	if err := project.journal("lock", relPath, "stored", hash); err != nil {
+		os.Remove(vaultPath)
+		return err
+	}
 
 	// Copy to mirror backup (single redundant copy; fail lock if backup fails)
 	backupPath := vault.BackupPath(relPath)
@@ -147,40 +285,146 @@ func LockFile(project *Project, vault *Vault, manifest *Manifest, relPath string
 		os.Remove(vaultPath)
 		return fmt.Errorf("copying to backup vault: %w", err)
 	}
+	project.journalStep("lock", relPath, "backed-up", hash)
 
 	// Point of no return: vault copy verified. Write placeholder over original.
-	placeholder := GeneratePlaceholder(relPath)
+	locked := &FileEntry{
+		State:       "locked",
+		LockedAt:    time.Now().UTC().Format(time.RFC3339),
+		Hash:        hash,
+		Placeholder: opts.Placeholder,
+		Note:        opts.Note,
+		Canary:      canaryFor(entry, opts.Canary),
+		Hidden:      srcPath != absPath,
+	}
+	placeholder, placeholderHash, err := lockPlaceholder(project, vault, relPath, locked)
+	if err != nil {
+		removeVaultCopies(vault, relPath)
+		return err
+	}
+	if placeholderHash != "" {
+		if err := project.journal("lock", relPath, placeholderStep(opts.Placeholder), placeholderHash); err != nil {
+			removeVaultCopies(vault, relPath)
+			return err
+		}
+	}
+	if locked.Canary != "" {
+		// The journal's hash field carries the token, for recovery to record it
+		project.journalStep("lock", relPath, "canary", locked.Canary)
+	}
 	r := strings.NewReader(string(placeholder))
 	if err := atomic.WriteFile(absPath, r); err != nil {
+		// The original is untouched, so nothing may stay behind in the vault
+		removeVaultCopies(vault, relPath)
 		return fmt.Errorf("writing placeholder: %w", err)
 	}
+	project.journalStep("lock", relPath, "placeholder", hash)
+	if srcPath != absPath {
+		if err := os.Remove(srcPath); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: removing original: %v\n", filepath.FromSlash(relPath), err)
+		}
+	}
 
 	// Update manifest entry
-	manifest.Files[relPath] = &FileEntry{
-		State:    "locked",
-		LockedAt: time.Now().UTC().Format(time.RFC3339),
-		Hash:     hash,
-	}
+	locked.PlaceholderHash = placeholderHash
+	manifest.setEntry(relPath, locked)
+	warnCapture(project, vault, relPath, "lock")
 	return nil
 }
 
-// UnlockFile replaces a placeholder with a symlink to the vault copy.
-func UnlockFile(project *Project, vault *Vault, manifest *Manifest, relPath string) error {
-	// Symlink capability check (cached)
-	if err := ensureSymlinkSupport(project.IgnlnkDir); err != nil {
+// replacePlaceholder rewrites a locked file's intact placeholder with another mode or note.
+func replacePlaceholder(project *Project, vault *Vault, manifest *Manifest, entry *FileEntry, relPath string, opts LockOptions) error {
+	absPath := project.AbsPath(relPath)
+	info, err := os.Lstat(absPath)
+	if err != nil || !info.Mode().IsRegular() || !project.isPlaceholderAt(absPath, relPath, entry, info.Size()) {
+		return fmt.Errorf("refusing to replace placeholder of %s: it is not intact — run 'ignlnk verify'", relPath)
+	}
+	updated := *entry
+	updated.Placeholder, updated.Note = opts.Placeholder, opts.Note
+	updated.Canary = canaryFor(entry, opts.Canary)
+	placeholder, placeholderHash, err := lockPlaceholder(project, vault, relPath, &updated)
+	if err != nil {
+		return err
+	}
+	if err := project.journal("relock", relPath, "begin", entry.Hash); err != nil {
 		return err
 	}
+	if placeholderHash != "" {
+		if err := project.journal("relock", relPath, placeholderStep(opts.Placeholder), placeholderHash); err != nil {
+			return err
+		}
+	}
+	if updated.Canary != entry.Canary {
+		project.journalStep("relock", relPath, "canary", updated.Canary)
+	}
+	if err := atomic.WriteFile(absPath, strings.NewReader(string(placeholder))); err != nil {
+		return fmt.Errorf("writing placeholder: %w", err)
+	}
+	project.journalStep("relock", relPath, "placeholder", "")
+	updated.PlaceholderHash = placeholderHash
+	manifest.setEntry(relPath, &updated)
+	return nil
+}
 
+// UnlockFile replaces a placeholder with a symlink to the vault copy, or with a real copy
+// if that is the file's or project's default unlock mode.
+func UnlockFile(project *Project, vault *Vault, manifest *Manifest, relPath string) error {
+	return UnlockFileAs(project, vault, manifest, relPath, "", false)
+}
+
+// UnlockFileAs is UnlockFile with an explicit unlock mode ("" = file/project default).
+// Copy mode writes the plaintext over the placeholder and sets state "unlocked-copy";
+// edits to the copy are synced back into the vault by commit and re-lock. With readOnly,
+// the unlocked file's write bits are stripped until re-lock and commit is refused.
+func UnlockFileAs(project *Project, vault *Vault, manifest *Manifest, relPath, mode string, readOnly bool) error {
 	// Idempotent: already unlocked = no-op
-	if entry, ok := manifest.Files[relPath]; ok && entry.State == "unlocked" {
+	entry := manifest.entry(relPath)
+	if entry != nil && entry.Unlocked() {
 		return nil
 	}
 
 	// Must be managed
-	entry, ok := manifest.Files[relPath]
-	if !ok {
+	if entry == nil {
 		return fmt.Errorf("file not managed: %s", relPath)
 	}
+	if entry.Dir {
+		return unlockDir(project, vault, manifest, entry, relPath, mode, readOnly)
+	}
+
+	cfg, err := project.Config()
+	if err != nil {
+		return err
+	}
+	mode, err = resolveUnlockMode(cfg, entry, mode)
+	if err != nil {
+		return err
+	}
+	if entry.Hidden {
+		if err := checkHiddenUnlock(project, vault, relPath, mode); err != nil {
+			return err
+		}
+	}
+
+	// Symlink capability check (cached)
+	if mode == UnlockModeSymlink {
+		if err := ensureSymlinkSupport(project.IgnlnkDir); err != nil {
+			return err
+		}
+		if err := vault.requirePlainDir(); err != nil {
+			return fmt.Errorf("refusing to unlock %s: %w", relPath, err)
+		}
+	}
+
+	// Opt-in self-heal: repair the vault copy from its backup before using it
+	if cfg.AutoRepair {
+		repaired, err := repairVaultCopy(vault, entry, relPath)
+		if err != nil {
+			return fmt.Errorf("refusing to unlock %s: %w", relPath, err)
+		}
+		if repaired != "" {
+			fmt.Fprintf(os.Stderr, "warning: %s: %s\n", filepath.FromSlash(relPath), repaired)
+		}
+	}
 
 	// Verify vault file exists
 	vaultPath := vault.FilePath(relPath)
@@ -188,66 +432,280 @@ func UnlockFile(project *Project, vault *Vault, manifest *Manifest, relPath stri
 		return fmt.Errorf("vault file missing: %w", err)
 	}
 
-	// Verify vault file hash
-	vaultHash, err := HashFile(vaultPath)
-	if err != nil {
-		return fmt.Errorf("verifying vault file: %w", err)
-	}
-	if vaultHash != entry.Hash {
-		fmt.Fprintf(os.Stderr, "warning: vault file hash mismatch for %s\n", filepath.FromSlash(relPath))
-	}
-
 	absPath := project.AbsPath(relPath)
 
 	// Check if placeholder exists and is actually a placeholder
-	if info, err := os.Lstat(absPath); err == nil {
+	info, statErr := os.Lstat(absPath)
+	if statErr == nil {
 		if info.Mode().IsDir() {
 			return fmt.Errorf("refusing to unlock %s: path is a directory, expected file or symlink", relPath)
 		}
 		if !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0 {
 			return fmt.Errorf("refusing to unlock %s: path is not a file or symlink (got %s)", relPath, info.Mode().String())
 		}
-		if info.Mode().IsRegular() && !IsPlaceholderFor(absPath, relPath, info.Size()) {
+		if info.Mode().IsRegular() && entry.Placeholder == PlaceholderRegions {
+			// Edits to the readable portion are reassembled with the regions first
+			if _, err := commitRegionEdits(project, vault, manifest, entry, relPath); err != nil {
+				return fmt.Errorf("refusing to unlock %s: %w", relPath, err)
+			}
+			entry = manifest.entry(relPath)
+		}
+		if info.Mode().IsRegular() && !project.isPlaceholderAt(absPath, relPath, entry, info.Size()) {
 			return fmt.Errorf("refusing to unlock %s: path contains user data (not a placeholder). Copy your content elsewhere, then run 'ignlnk unlock %s' again", relPath, relPath)
 		}
-		// Remove the placeholder (or symlink) before creating new symlink
+	}
+
+	if err := project.journal("unlock", relPath, "begin", entry.Hash); err != nil {
+		return err
+	}
+
+	if mode == UnlockModeCopy {
+		// Materialize the plaintext next to the placeholder, verify, then swap it in
+		tmp := absPath + tempSuffix
+		if err := vault.restoreFile(vaultPath, tmp); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("copying vault file: %w", err)
+		}
+		hash, err := HashFile(tmp)
+		if err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("verifying vault file: %w", err)
+		}
+		if hash != entry.Hash {
+			fmt.Fprintf(os.Stderr, "warning: vault file hash mismatch for %s — run 'ignlnk repair %s'\n", filepath.FromSlash(relPath), filepath.FromSlash(relPath))
+		}
+		updated := *entry
+		if readOnly {
+			if updated.Perm, err = makeReadOnly(tmp); err != nil {
+				os.Remove(tmp)
+				return fmt.Errorf("making copy read-only: %w", err)
+			}
+			updated.ReadOnly = true
+		}
+		if err := os.Rename(tmp, absPath); err != nil {
+			os.Remove(tmp)
+			return fmt.Errorf("replacing placeholder: %w", err)
+		}
+		project.journalStep("unlock", relPath, "copy", "")
+
+		updated.State = "unlocked-copy"
+		manifest.setEntry(relPath, &updated)
+		return nil
+	}
+
+	// Encrypted vault: decrypt into the private working copy the symlink will target
+	workPath := vault.WorkPath(relPath)
+	if workPath != vaultPath {
+		if err := vault.restoreFile(vaultPath, workPath); err != nil {
+			return fmt.Errorf("decrypting vault file: %w", err)
+		}
+	}
+
+	// Verify vault file hash
+	vaultHash, err := HashFile(workPath)
+	if err != nil {
+		removeWorkCopy(vault, relPath)
+		return fmt.Errorf("verifying vault file: %w", err)
+	}
+	if vaultHash != entry.Hash {
+		fmt.Fprintf(os.Stderr, "warning: vault file hash mismatch for %s — run 'ignlnk repair %s'\n", filepath.FromSlash(relPath), filepath.FromSlash(relPath))
+	}
+
+	// Read-only: strip write bits from the symlink target
+	updated := *entry
+	if readOnly {
+		if updated.Perm, err = makeReadOnly(workPath); err != nil {
+			removeWorkCopy(vault, relPath)
+			return fmt.Errorf("making vault file read-only: %w", err)
+		}
+		updated.ReadOnly = true
+	}
+
+	// Remove the placeholder (or symlink) before creating new symlink
+	if statErr == nil {
 		if err := os.Remove(absPath); err != nil {
+			removeWorkCopy(vault, relPath)
 			return fmt.Errorf("removing placeholder: %w", err)
 		}
 	}
 
-	// Create symlink: original path -> vault absolute path
-	if err := os.Symlink(vaultPath, absPath); err != nil {
+	// Create symlink: original path (a hidden file's real one) -> vault absolute path (or
+	// decrypted working copy)
+	if err := os.Symlink(workPath, origPath(project, vault, entry, relPath)); err != nil {
 		return fmt.Errorf("creating symlink: %w", err)
 	}
+	project.journalStep("unlock", relPath, "symlink", "")
 
 	// Update manifest
-	entry.State = "unlocked"
+	updated.State = "unlocked"
+	manifest.setEntry(relPath, &updated)
 	return nil
 }
 
+// CommitFile accepts edits made to an unlocked file as its new vault content without
+// re-locking: the manifest hash and mirror backup are updated to match.
+// Returns false if the content was unchanged.
+func CommitFile(project *Project, vault *Vault, manifest *Manifest, relPath string) (bool, error) {
+	entry, ok := manifest.Files[relPath]
+	if !ok {
+		return false, fmt.Errorf("file not managed: %s", relPath)
+	}
+	if !entry.Unlocked() {
+		return false, fmt.Errorf("%s is %s — only unlocked files can have edits to commit", relPath, entry.State)
+	}
+	if entry.ReadOnly {
+		return false, fmt.Errorf("%s is unlocked read-only — changes from a read-only unlock cannot be committed", relPath)
+	}
+	if entry.State == "unlocked" {
+		if err := vault.requirePlainDir(); err != nil {
+			return false, err
+		}
+	}
+
+	if err := project.journal("commit", relPath, "begin", entry.Hash); err != nil {
+		return false, err
+	}
+	if entry.Dir {
+		changed, err := commitDir(vault, entry, relPath)
+		if changed {
+			project.journalStep("commit", relPath, "committed", entry.Hash)
+		}
+		return changed, err
+	}
+	changed, err := commitVaultCopy(vault, entry, relPath, liveCopy(project, vault, entry, relPath))
+	if err != nil {
+		return false, err
+	}
+	if changed {
+		project.journalStep("commit", relPath, "committed", entry.Hash)
+		warnCapture(project, vault, relPath, "commit")
+	}
+	return changed, nil
+}
+
+// RelinkFile points an unlocked file's symlink at the vault's current location, e.g. after
+// the project or home directory moved. Returns false if the symlink was already correct.
+func RelinkFile(project *Project, vault *Vault, manifest *Manifest, relPath string) (bool, error) {
+	entry, ok := manifest.Files[relPath]
+	if !ok {
+		return false, fmt.Errorf("file not managed: %s", relPath)
+	}
+	if entry.State != "unlocked" {
+		return false, nil
+	}
+	if err := vault.requirePlainDir(); err != nil {
+		return false, err
+	}
+
+	absPath := livePath(project, vault, entry, relPath)
+	info, err := os.Lstat(absPath)
+	if err != nil {
+		return false, fmt.Errorf("stat before relink: %w", err)
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return false, fmt.Errorf("refusing to relink %s: path is not a symlink (may contain user data)", relPath)
+	}
+
+	target := vault.WorkPath(relPath)
+	current, err := os.Readlink(absPath)
+	if err != nil {
+		return false, fmt.Errorf("reading symlink: %w", err)
+	}
+	if current == target {
+		return false, nil
+	}
+
+	if _, err := os.Stat(target); err != nil {
+		vaultPath := vault.FilePath(relPath)
+		if target == vaultPath {
+			return false, fmt.Errorf("vault file missing: %w", err)
+		}
+		// Encrypted vault: carry over the old working copy (it may hold edits), or
+		// decrypt a fresh one.
+		if _, err := os.Stat(current); err == nil {
+			err = copyFile(current, target)
+		} else {
+			err = vault.restoreFile(vaultPath, target)
+		}
+		if err != nil {
+			return false, fmt.Errorf("preparing working copy: %w", err)
+		}
+	}
+
+	// Swap via temp symlink + rename so the path never disappears
+	tmp := absPath + tempSuffix
+	os.Remove(tmp)
+	if err := os.Symlink(target, tmp); err != nil {
+		return false, fmt.Errorf("creating symlink: %w", err)
+	}
+	if err := os.Rename(tmp, absPath); err != nil {
+		os.Remove(tmp)
+		return false, fmt.Errorf("replacing symlink: %w", err)
+	}
+	return true, nil
+}
+
 // ForgetFile restores a file from vault and removes it from management.
 func ForgetFile(project *Project, vault *Vault, manifest *Manifest, relPath string) error {
 	entry, ok := manifest.Files[relPath]
 	if !ok {
 		return fmt.Errorf("file not managed: %s", relPath)
 	}
+	if entry.Dir {
+		return forgetDir(project, vault, manifest, entry, relPath)
+	}
 
-	absPath := project.AbsPath(relPath)
+	absPath := livePath(project, vault, entry, relPath)
+	restorePath := origPath(project, vault, entry, relPath)
 	vaultPath := vault.FilePath(relPath)
+	info, statErr := os.Lstat(absPath)
+	if restorePath != absPath {
+		if _, err := os.Lstat(restorePath); err == nil {
+			return fmt.Errorf("refusing to forget %s: %s already exists", relPath, vault.HiddenName(relPath))
+		}
+	}
+
+	// Unlocked as a copy: the project file already holds the current content, edits included
+	keepCopy := entry.State == "unlocked-copy" && statErr == nil && info.Mode().IsRegular() &&
+		!project.isPlaceholderAt(absPath, relPath, entry, info.Size())
+
+	if entry.State == "unlocked" {
+		if err := vault.requirePlainDir(); err != nil {
+			return fmt.Errorf("refusing to forget %s: %w", relPath, err)
+		}
+	}
+
+	// The current content is the decrypted working copy if unlocked from an encrypted
+	// vault (it holds any edits), otherwise the vault file itself.
+	source := vaultPath
+	if workPath := vault.WorkPath(relPath); workPath != vaultPath && entry.State == "unlocked" {
+		if _, err := os.Stat(workPath); err == nil {
+			source = workPath
+		}
+	}
+	if !keepCopy && source == vaultPath && vault.isSealedFile(vaultPath) {
+		if err := vault.requireKey(); err != nil {
+			return err
+		}
+	}
 
 	// Remove whatever is at the original path (placeholder or symlink)
 	// Verify path is expected type before destructive operation
-	if info, err := os.Lstat(absPath); err == nil {
+	if statErr == nil && !keepCopy {
 		if info.Mode().IsDir() {
 			return fmt.Errorf("refusing to forget %s: path is a directory, expected file or symlink", relPath)
 		}
 		if !info.Mode().IsRegular() && info.Mode()&os.ModeSymlink == 0 {
 			return fmt.Errorf("refusing to forget %s: path is not a file or symlink (got %s)", relPath, info.Mode().String())
 		}
-		if info.Mode().IsRegular() && !IsPlaceholderFor(absPath, relPath, info.Size()) {
+		if info.Mode().IsRegular() && !project.isPlaceholderAt(absPath, relPath, entry, info.Size()) {
 			return fmt.Errorf("refusing to forget %s: path contains user data (not a placeholder or symlink). Run 'ignlnk lock %s' first to lock, then forget", relPath, relPath)
 		}
+	}
+	if err := project.journal("forget", relPath, "begin", entry.Hash); err != nil {
+		return err
+	}
+	if statErr == nil && !keepCopy {
 		// Path is symlink or placeholder — safe to remove
 		if err := os.Remove(absPath); err != nil {
 			return fmt.Errorf("removing existing file: %w", err)
@@ -255,56 +713,51 @@ func ForgetFile(project *Project, vault *Vault, manifest *Manifest, relPath stri
 	}
 
 	// Copy vault file back to original location
-	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
-		return fmt.Errorf("creating parent directory: %w", err)
+	if keepCopy && entry.ReadOnly {
+		if err := os.Chmod(absPath, os.FileMode(entry.Perm)); err != nil {
+			return fmt.Errorf("restoring permissions: %w", err)
+		}
 	}
-	if err := copyFile(vaultPath, absPath); err != nil {
-		return fmt.Errorf("restoring file from vault: %w", err)
+	if !keepCopy {
+		if err := os.MkdirAll(filepath.Dir(restorePath), 0o755); err != nil {
+			return fmt.Errorf("creating parent directory: %w", err)
+		}
+		if err := vault.restoreFile(source, restorePath); err != nil {
+			return fmt.Errorf("restoring file from vault: %w", err)
+		}
 	}
+	project.journalStep("forget", relPath, "restored", "")
 
-	// Remove vault file and empty parent dirs
+	// Remove vault file, working copy and empty parent dirs
 	os.Remove(vaultPath)
 	removeEmptyParents(filepath.Dir(vaultPath), vault.Dir)
+	removeWorkCopy(vault, relPath)
 
 	// Remove backup and empty backup parents
 	backupPath := vault.BackupPath(relPath)
 	os.Remove(backupPath)
 	removeEmptyParents(filepath.Dir(backupPath), vault.BackupDir())
 
+	if err := dropHistory(vault, relPath); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: removing history for %s: %v\n", filepath.FromSlash(relPath), err)
+	}
+
+	if entry.Hidden {
+		vault.forgetHiddenName(relPath)
+	}
+
 	// Remove from manifest (in-memory; caller saves)
 	delete(manifest.Files, relPath)
-	_ = entry // suppress unused warning
 	return nil
 }
 
-// IsPlaceholder checks if a file at the given path is an ignlnk placeholder.
-func IsPlaceholder(path string) bool {
-	f, err := os.Open(path)
-	if err != nil {
-		return false
+// FileStatus returns the actual filesystem state of a managed file. Unlocked files are
+// hashed to detect "dirty"; cache skips that for unchanged files (nil = always hash).
+func FileStatus(project *Project, vault *Vault, entry *FileEntry, relPath string, cache *StatCache) string {
+	if entry.Dir {
+		return dirStatus(project, vault, entry, relPath)
 	}
-	defer f.Close()
-	buf := make([]byte, len(placeholderPrefix))
-	n, err := f.Read(buf)
-	if err != nil || n < len(placeholderPrefix) {
-		return false
-	}
-	return string(buf) == placeholderPrefix
-}
-
-// IsPlaceholderFor checks if the file at path is exactly the ignlnk placeholder for relPath.
-// Requires size match (from Lstat) to defeat prefix spoof (file with appended content).
-func IsPlaceholderFor(path, relPath string, size int64) bool {
-	expected := int64(len(GeneratePlaceholder(relPath)))
-	if size != expected {
-		return false
-	}
-	return IsPlaceholder(path)
-}
-
-// FileStatus returns the actual filesystem state of a managed file.
-func FileStatus(project *Project, vault *Vault, entry *FileEntry, relPath string) string {
-	absPath := project.AbsPath(relPath)
+	absPath := livePath(project, vault, entry, relPath)
 	vaultPath := vault.FilePath(relPath)
 
 	// Check vault file exists
@@ -321,19 +774,36 @@ func FileStatus(project *Project, vault *Vault, entry *FileEntry, relPath string
 
 	// Symlink = unlocked state
 	if info.Mode()&os.ModeSymlink != 0 {
-		// Check if vault file has been modified
-		hash, err := HashFile(vaultPath)
+		// Check if vault file (or decrypted working copy) has been modified
+		hash, err := cache.hash(relPath, vault.WorkPath(relPath))
 		if err == nil && hash != entry.Hash {
 			return "dirty"
 		}
+		if entry.ReadOnly {
+			return "unlocked (ro)"
+		}
 		return "unlocked"
 	}
 
 	// Regular file = should be placeholder
 	if info.Mode().IsRegular() {
-		if IsPlaceholderFor(absPath, relPath, info.Size()) {
+		if project.isPlaceholderAt(absPath, relPath, entry, info.Size()) {
 			return "locked"
 		}
+		if regionsEdited(project, entry, relPath) {
+			return "edited"
+		}
+		// Unlocked as a copy: the file itself holds the content
+		if entry.State == "unlocked-copy" {
+			hash, err := cache.hash(relPath, absPath)
+			if err == nil && hash != entry.Hash {
+				return "dirty"
+			}
+			if entry.ReadOnly {
+				return "unlocked (ro)"
+			}
+			return "unlocked-copy"
+		}
 		return "tampered"
 	}
 
@@ -347,7 +817,11 @@ func copyFile(src, dst string) error {
 		return err
 	}
 	defer in.Close()
+	return writeFile(dst, in)
+}
 
+// writeFile copies r into dst, creating parent directories.
+func writeFile(dst string, r io.Reader) error {
 	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
 		return err
 	}
@@ -358,12 +832,160 @@ func copyFile(src, dst string) error {
 	}
 	defer out.Close()
 
-	if _, err := io.Copy(out, in); err != nil {
+	if _, err := io.Copy(out, r); err != nil {
 		return err
 	}
 	return out.Close()
 }
 
+// warnCapture records the vault file of relPath in its history. History is auxiliary,
+// so failures are reported but never fail the operation.
+func warnCapture(project *Project, vault *Vault, relPath, reason string) {
+	if err := captureRevision(project, vault, relPath, vault.FilePath(relPath), reason); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: recording history for %s: %v\n", filepath.FromSlash(relPath), err)
+	}
+}
+
+// commitVaultCopy accepts the current content of an unlocked file: stores src, the file
+// holding the edits (see liveCopy), into the vault unless it is the vault file itself,
+// verifies the vault copy, rotates the mirror backup to it and records its hash in entry
+// (in-memory; caller saves). Returns false if the content matched entry.Hash.
+func commitVaultCopy(vault *Vault, entry *FileEntry, relPath, src string) (bool, error) {
+	vaultPath := vault.FilePath(relPath)
+
+	// Decrypted working copy or project copy: edits live there until stored back in.
+	if src != vaultPath {
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			return false, nil
+		}
+		srcHash, err := HashFile(src)
+		if err != nil {
+			return false, fmt.Errorf("hashing edited copy: %w", err)
+		}
+		if srcHash == entry.Hash {
+			return false, nil
+		}
+		// Store beside the vault file and verify before replacing it
+		tmp := vaultPath + tempSuffix
+		if err := vault.storeFile(src, tmp); err != nil {
+			os.Remove(tmp)
+			return false, fmt.Errorf("saving edits to vault: %w", err)
+		}
+		if stored, err := vault.hashStored(tmp); err != nil || stored != srcHash {
+			os.Remove(tmp)
+			return false, fmt.Errorf("vault copy hash mismatch after saving edits — edits kept at %s", src)
+		}
+		if err := os.Rename(tmp, vaultPath); err != nil {
+			os.Remove(tmp)
+			return false, fmt.Errorf("saving edits to vault: %w", err)
+		}
+	}
+
+	hash, err := vault.hashStored(vaultPath)
+	if err != nil {
+		return false, fmt.Errorf("verifying vault copy: %w", err)
+	}
+	if hash == entry.Hash {
+		return false, nil
+	}
+
+	backupPath := vault.BackupPath(relPath)
+	if err := os.MkdirAll(filepath.Dir(backupPath), 0o755); err != nil {
+		return false, fmt.Errorf("creating backup directory: %w", err)
+	}
+	if err := replaceFile(vaultPath, backupPath); err != nil {
+		return false, fmt.Errorf("rotating backup: %w", err)
+	}
+	entry.Hash = hash
+	return true, nil
+}
+
+// liveCopy returns the file holding an unlocked file's current content: the project copy
+// in copy mode, else the symlink target (vault file, or decrypted working copy).
+func liveCopy(project *Project, vault *Vault, entry *FileEntry, relPath string) string {
+	if entry.State == "unlocked-copy" {
+		return project.AbsPath(relPath)
+	}
+	return vault.WorkPath(relPath)
+}
+
+// makeReadOnly strips the write bits from path and returns its previous permissions.
+func makeReadOnly(path string) (uint32, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+	perm := info.Mode().Perm()
+	if err := os.Chmod(path, perm&^0o222); err != nil {
+		return 0, err
+	}
+	return uint32(perm), nil
+}
+
+// discardReadOnlyChanges ends a read-only unlock: since its changes are never committed,
+// a modified vault file is put back from the backup (a modified project or working copy
+// is simply not synced), then the live copy's permissions are restored.
+func discardReadOnlyChanges(project *Project, vault *Vault, entry *FileEntry, relPath string) error {
+	src := liveCopy(project, vault, entry, relPath)
+	if hash, err := HashFile(src); err == nil && hash != entry.Hash {
+		fmt.Fprintf(os.Stderr, "warning: %s changed during read-only unlock — changes discarded\n", filepath.FromSlash(relPath))
+		if vaultPath := vault.FilePath(relPath); src == vaultPath {
+			backupPath := vault.BackupPath(relPath)
+			if !storedMatches(vault, backupPath, entry.Hash) {
+				return fmt.Errorf("vault copy of %s changed during read-only unlock and its backup does not match — run 'ignlnk repair %s'", relPath, relPath)
+			}
+			if err := replaceFile(backupPath, vaultPath); err != nil {
+				return fmt.Errorf("restoring vault copy from backup: %w", err)
+			}
+		}
+	}
+	if err := os.Chmod(src, os.FileMode(entry.Perm)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("restoring permissions: %w", err)
+	}
+	return nil
+}
+
+// resolveUnlockMode picks the unlock mode: explicit, else the file's default, else the
+// project's, else symlink.
+func resolveUnlockMode(cfg *Config, entry *FileEntry, mode string) (string, error) {
+	for _, m := range []string{mode, entry.UnlockMode, cfg.UnlockMode} {
+		switch m {
+		case "":
+			continue
+		case UnlockModeSymlink, UnlockModeCopy:
+			return m, nil
+		default:
+			return "", fmt.Errorf("unknown unlock mode %q (expected %q or %q)", m, UnlockModeSymlink, UnlockModeCopy)
+		}
+	}
+	return UnlockModeSymlink, nil
+}
+
+// removeWorkCopy deletes an encrypted vault's decrypted working copy, if any, and the
+// runtime directory's per-vault directory once empty.
+func removeWorkCopy(vault *Vault, relPath string) {
+	workPath := vault.WorkPath(relPath)
+	if workPath == "" || workPath == vault.FilePath(relPath) {
+		return
+	}
+	os.Remove(workPath)
+	removeEmptyParents(filepath.Dir(workPath), filepath.Dir(vault.PlainDir()))
+}
+
+// replaceFile copies src over dst via a temp file and rename, so dst is never half-written.
+func replaceFile(src, dst string) error {
+	tmp := dst + tempSuffix
+	if err := copyFile(src, tmp); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
 // removeEmptyParents removes empty directories from dir up to (but not including) stopAt.
 func removeEmptyParents(dir, stopAt string) {
 	for dir != stopAt && dir != filepath.Dir(dir) {
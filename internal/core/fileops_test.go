@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // setupLockFileTest creates a temp dir with .ignlnk, vault, manifest.
@@ -520,3 +521,305 @@ func TestForgetFileDirectoryRefused(t *testing.T) {
 		t.Fatal("vault content should be unchanged")
 	}
 }
+
+func TestLockFileRelockAcceptsEdits(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "config/secret.txt"
+	absPath := p.AbsPath(relPath)
+	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(absPath, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	if err := UnlockFile(p, v, m, relPath); err != nil {
+		t.Fatalf("UnlockFile failed: %v", err)
+	}
+
+	// Edit through the symlink
+	edited := []byte("edited while unlocked")
+	if err := os.WriteFile(absPath, edited, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+
+	editedHash, err := HashFile(v.FilePath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Files[relPath].Hash != editedHash {
+		t.Fatalf("expected manifest hash updated to %s, got %s", editedHash, m.Files[relPath].Hash)
+	}
+	backup, err := os.ReadFile(v.BackupPath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(backup) != string(edited) {
+		t.Fatalf("expected backup rotated to edited content, got %q", backup)
+	}
+}
+
+func TestCommitFile(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	if err := os.WriteFile(absPath, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := CommitFile(p, v, m, relPath); err == nil {
+		t.Fatal("expected CommitFile to fail for unmanaged file")
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	if _, err := CommitFile(p, v, m, relPath); err == nil {
+		t.Fatal("expected CommitFile to fail for locked file")
+	}
+	if err := UnlockFile(p, v, m, relPath); err != nil {
+		t.Fatalf("UnlockFile failed: %v", err)
+	}
+
+	if changed, err := CommitFile(p, v, m, relPath); err != nil || changed {
+		t.Fatalf("expected unchanged commit, got changed=%v err=%v", changed, err)
+	}
+
+	if err := os.WriteFile(absPath, []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FileStatus(p, v, m.Files[relPath], relPath, nil); got != "dirty" {
+		t.Fatalf("expected dirty before commit, got %s", got)
+	}
+
+	changed, err := CommitFile(p, v, m, relPath)
+	if err != nil || !changed {
+		t.Fatalf("expected changed commit, got changed=%v err=%v", changed, err)
+	}
+	if got := FileStatus(p, v, m.Files[relPath], relPath, nil); got != "unlocked" {
+		t.Fatalf("expected unlocked after commit, got %s", got)
+	}
+	if m.Files[relPath].State != "unlocked" {
+		t.Fatalf("expected file to stay unlocked, got %s", m.Files[relPath].State)
+	}
+}
+
+func TestUnlockFileCopyModeSyncsOnRelock(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	if err := os.WriteFile(absPath, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	if err := UnlockFileAs(p, v, m, relPath, "bogus", false); err == nil {
+		t.Fatal("expected unknown unlock mode to fail")
+	}
+	if err := UnlockFileAs(p, v, m, relPath, UnlockModeCopy, false); err != nil {
+		t.Fatalf("UnlockFileAs failed: %v", err)
+	}
+
+	info, err := os.Lstat(absPath)
+	if err != nil || !info.Mode().IsRegular() {
+		t.Fatal("expected a regular file copy")
+	}
+	if m.Files[relPath].State != "unlocked-copy" {
+		t.Fatalf("expected unlocked-copy state, got %s", m.Files[relPath].State)
+	}
+	if got := FileStatus(p, v, m.Files[relPath], relPath, nil); got != "unlocked-copy" {
+		t.Fatalf("expected unlocked-copy status, got %s", got)
+	}
+
+	edited := []byte("edited copy")
+	if err := os.WriteFile(absPath, edited, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := FileStatus(p, v, m.Files[relPath], relPath, nil); got != "dirty" {
+		t.Fatalf("expected dirty after editing the copy, got %s", got)
+	}
+
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+	if got, err := os.ReadFile(v.FilePath(relPath)); err != nil || string(got) != string(edited) {
+		t.Fatalf("expected edits synced into vault, got %q, %v", got, err)
+	}
+	editedHash, err := HashFile(v.FilePath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Files[relPath].State != "locked" || m.Files[relPath].Hash != editedHash {
+		t.Fatalf("expected locked entry with hash %s, got %+v", editedHash, m.Files[relPath])
+	}
+	if got := FileStatus(p, v, m.Files[relPath], relPath, nil); got != "locked" {
+		t.Fatalf("expected placeholder after re-lock, got %s", got)
+	}
+}
+
+func TestUnlockFileReadOnly(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	vaultPath := v.FilePath(relPath)
+	if err := os.WriteFile(absPath, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	before, err := os.Stat(vaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := UnlockFileAs(p, v, m, relPath, "", true); err != nil {
+		t.Fatalf("UnlockFileAs failed: %v", err)
+	}
+
+	info, err := os.Stat(vaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm()&0o222 != 0 {
+		t.Fatalf("expected write bits stripped, got %v", info.Mode().Perm())
+	}
+	if got := FileStatus(p, v, m.Files[relPath], relPath, nil); got != "unlocked (ro)" {
+		t.Fatalf("expected unlocked (ro) status, got %s", got)
+	}
+	if _, err := CommitFile(p, v, m, relPath); err == nil {
+		t.Fatal("expected CommitFile to refuse a read-only unlock")
+	}
+
+	// A write that bypasses the permissions (e.g. root) is discarded on re-lock
+	if err := os.Chmod(vaultPath, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(vaultPath, []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+	if got, err := os.ReadFile(vaultPath); err != nil || string(got) != "original" {
+		t.Fatalf("expected vault copy restored, got %q, %v", got, err)
+	}
+	info, err = os.Stat(vaultPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != before.Mode().Perm() {
+		t.Fatalf("expected permissions %v restored, got %v", before.Mode().Perm(), info.Mode().Perm())
+	}
+	if entry := m.Files[relPath]; entry.State != "locked" || entry.ReadOnly {
+		t.Fatalf("expected locked entry without read-only flag, got %+v", entry)
+	}
+}
+
+func TestExpiredUnlocks(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	now := time.Now()
+	for relPath, expiresAt := range map[string]time.Time{"soon.txt": now.Add(-time.Second), "later.txt": now.Add(time.Hour)} {
+		if err := os.WriteFile(p.AbsPath(relPath), []byte(relPath), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := LockFile(p, v, m, relPath, false); err != nil {
+			t.Fatalf("LockFile failed: %v", err)
+		}
+		if err := UnlockFile(p, v, m, relPath); err != nil {
+			t.Fatalf("UnlockFile failed: %v", err)
+		}
+		m.Files[relPath].ExpiresAt = expiresAt.UTC().Format(time.RFC3339)
+	}
+
+	expired := m.ExpiredFiles(now)
+	if len(expired) != 1 || expired[0] != "soon.txt" {
+		t.Fatalf("expected only soon.txt expired, got %v", expired)
+	}
+	if next, ok := m.NextExpiry(); !ok || next.After(now) {
+		t.Fatalf("expected the past expiry first, got %v, %v", next, ok)
+	}
+
+	if err := LockFile(p, v, m, "soon.txt", false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+	if m.Files["soon.txt"].ExpiresAt != "" {
+		t.Fatal("expected re-lock to clear the expiry")
+	}
+	if expired := m.ExpiredFiles(now); len(expired) != 0 {
+		t.Fatalf("expected nothing expired after re-lock, got %v", expired)
+	}
+	if next, ok := m.NextExpiry(); !ok || next.Before(now) {
+		t.Fatalf("expected later.txt's expiry next, got %v, %v", next, ok)
+	}
+}
+
+func TestRelinkFile(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	vaultPath := v.FilePath(relPath)
+	if err := os.WriteFile(vaultPath, []byte("secret data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Symlink pointing at a vault location that no longer exists
+	if err := os.Symlink(filepath.Join(t.TempDir(), "old-home", "secret.txt"), absPath); err != nil {
+		t.Fatal(err)
+	}
+	m.Files[relPath] = &FileEntry{State: "unlocked", Hash: "sha256:fake"}
+
+	changed, err := RelinkFile(p, v, m, relPath)
+	if err != nil || !changed {
+		t.Fatalf("expected relink, got changed=%v err=%v", changed, err)
+	}
+	target, err := os.Readlink(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if target != vaultPath {
+		t.Fatalf("expected symlink to %s, got %s", vaultPath, target)
+	}
+
+	if changed, err := RelinkFile(p, v, m, relPath); err != nil || changed {
+		t.Fatalf("expected no-op relink, got changed=%v err=%v", changed, err)
+	}
+}
@@ -0,0 +1,229 @@
+package core
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/natefinch/atomic"
+)
+
+// A hidden file is managed under a generic name in its directory, so neither the project
+// tree nor the vault reveals what it is called: while locked its placeholder is
+// HiddenPrefix + 8 hex characters + ".md", and that path is its manifest key. The real
+// name is kept only in the vault-side names file, and the file reappears under it when
+// unlocked (as a symlink) or forgotten.
+const HiddenPrefix = "IGNLNK_HIDDEN_"
+
+// hiddenNames is ~/.ignlnk/vault/<uid>.names.json: manifest key -> real relative path.
+// In an encrypted vault the file is sealed with the vault key like the vault's files.
+type hiddenNames struct {
+	Version int               `json:"version"`
+	Names   map[string]string `json:"names"`
+}
+
+// NamesPath returns the path to the hidden names file (~/.ignlnk/vault/<uid>.names.json).
+func (v *Vault) NamesPath() string {
+	return filepath.Join(filepath.Dir(v.Dir), v.UID+".names.json")
+}
+
+func (v *Vault) loadNames() (*hiddenNames, error) {
+	data, err := os.ReadFile(v.NamesPath())
+	if os.IsNotExist(err) {
+		return &hiddenNames{Version: 1, Names: make(map[string]string)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading hidden names: %w", err)
+	}
+	if v.isSealedFile(v.NamesPath()) {
+		if err := v.requireKey(); err != nil {
+			return nil, err
+		}
+		var plain bytes.Buffer
+		if err := decryptStream(&plain, bytes.NewReader(data), v.key); err != nil {
+			return nil, fmt.Errorf("decrypting hidden names: %w", err)
+		}
+		data = plain.Bytes()
+	}
+	var n hiddenNames
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("parsing hidden names: %w", err)
+	}
+	if n.Names == nil {
+		n.Names = make(map[string]string)
+	}
+	return &n, nil
+}
+
+// updateNames runs fn on the loaded names and saves the result (mode 0600, and sealed in
+// an encrypted vault: the names are what hiding protects).
+func (v *Vault) updateNames(fn func(names map[string]string)) error {
+	v.namesMu.Lock()
+	defer v.namesMu.Unlock()
+	n, err := v.loadNames()
+	if err != nil {
+		return err
+	}
+	fn(n.Names)
+	data, err := json.MarshalIndent(n, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling hidden names: %w", err)
+	}
+	data = append(data, '\n')
+	if err := os.MkdirAll(filepath.Dir(v.NamesPath()), 0o755); err != nil {
+		return fmt.Errorf("creating vault directory: %w", err)
+	}
+	if v.Encrypted() {
+		if err := v.requireKey(); err != nil {
+			return err
+		}
+		if err := writeEncrypted(v.NamesPath(), bytes.NewReader(data), v.key); err != nil {
+			return fmt.Errorf("writing hidden names: %w", err)
+		}
+		return nil
+	}
+	if err := atomic.WriteFile(v.NamesPath(), bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("writing hidden names: %w", err)
+	}
+	return os.Chmod(v.NamesPath(), 0o600)
+}
+
+// HiddenNames returns every hidden file's real relative path by manifest key.
+func (v *Vault) HiddenNames() (map[string]string, error) {
+	v.namesMu.Lock()
+	defer v.namesMu.Unlock()
+	n, err := v.loadNames()
+	if err != nil {
+		return nil, err
+	}
+	return n.Names, nil
+}
+
+// HiddenName returns the real relative path of the hidden file managed as key, or "".
+func (v *Vault) HiddenName(key string) string {
+	if !strings.HasPrefix(path.Base(key), HiddenPrefix) {
+		return ""
+	}
+	names, err := v.HiddenNames()
+	if err != nil {
+		return ""
+	}
+	return names[key]
+}
+
+// forgetHiddenName drops key's names entry; failures are reported, not returned, as a
+// stale entry only names a file that is no longer managed.
+func (v *Vault) forgetHiddenName(key string) {
+	if err := v.updateNames(func(names map[string]string) { delete(names, key) }); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %s: %v\n", filepath.FromSlash(key), err)
+	}
+}
+
+// ManagedPath is Project.RelPath for a command argument naming a managed file: the real
+// path of a hidden file resolves to its manifest key.
+func ManagedPath(project *Project, vault *Vault, manifest *Manifest, arg string) (string, error) {
+	relPath, err := project.RelPath(arg)
+	if err != nil {
+		return "", err
+	}
+	if _, ok := manifest.Files[relPath]; ok {
+		return relPath, nil
+	}
+	names, err := vault.HiddenNames()
+	if err != nil {
+		return "", err
+	}
+	for key, name := range names {
+		if _, ok := manifest.Files[key]; ok && name == relPath {
+			return key, nil
+		}
+	}
+	return relPath, nil
+}
+
+// newHiddenKey returns an unused generic placeholder path in relPath's directory.
+func newHiddenKey(project *Project, manifest *Manifest, relPath string) string {
+	for {
+		b := make([]byte, 4)
+		if _, err := rand.Read(b); err != nil {
+			panic(fmt.Sprintf("crypto/rand failed: %v", err))
+		}
+		key := path.Join(path.Dir(relPath), HiddenPrefix+hex.EncodeToString(b)+".md")
+		if manifest.entry(key) == nil && !fileExists(project.AbsPath(key)) {
+			return key
+		}
+	}
+}
+
+// origPath returns where relPath's file appears when unlocked or forgotten: its real
+// path if hidden, else the placeholder path itself.
+func origPath(project *Project, vault *Vault, entry *FileEntry, relPath string) string {
+	if entry != nil && entry.Hidden {
+		if name := vault.HiddenName(relPath); name != "" {
+			return project.AbsPath(name)
+		}
+	}
+	return project.AbsPath(relPath)
+}
+
+// livePath returns where relPath's file currently is in the project: the real path of
+// an unlocked hidden file, else the manifest path.
+func livePath(project *Project, vault *Vault, entry *FileEntry, relPath string) string {
+	if entry.Unlocked() {
+		return origPath(project, vault, entry, relPath)
+	}
+	return project.AbsPath(relPath)
+}
+
+// lockHidden locks the unmanaged file at relPath under a new generic placeholder name,
+// recording its real name vault-side first so recovery can find the original.
+func lockHidden(project *Project, vault *Vault, manifest *Manifest, relPath string, opts LockOptions) error {
+	if strings.HasPrefix(path.Base(relPath), HiddenPrefix) {
+		return fmt.Errorf("%s already has a hidden placeholder name", relPath)
+	}
+	info, err := os.Lstat(project.AbsPath(relPath))
+	if err != nil {
+		return fmt.Errorf("file not found: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("not a regular file: %s", relPath)
+	}
+	if opts.Placeholder != "" {
+		fmt.Fprintf(os.Stderr, "warning: %s: a %s placeholder would reveal the file's content, using the plain one\n", filepath.FromSlash(relPath), opts.Placeholder)
+		opts.Placeholder = ""
+	}
+	key := newHiddenKey(project, manifest, relPath)
+	if err := vault.updateNames(func(names map[string]string) { names[key] = relPath }); err != nil {
+		return err
+	}
+	if err := lockNew(project, vault, manifest, nil, key, project.AbsPath(relPath), opts); err != nil {
+		// Once a vault copy exists, recovery needs the name to put the original back
+		if !fileExists(vault.FilePath(key)) {
+			vault.forgetHiddenName(key)
+		}
+		return err
+	}
+	return nil
+}
+
+// checkHiddenUnlock refuses to unlock a hidden file in a way that would expose it under
+// its placeholder name, or over whatever now occupies its real path.
+func checkHiddenUnlock(project *Project, vault *Vault, relPath, mode string) error {
+	if mode == UnlockModeCopy {
+		return fmt.Errorf("refusing to unlock %s as a copy: a hidden file unlocks only as a symlink", relPath)
+	}
+	name := vault.HiddenName(relPath)
+	if name == "" {
+		return fmt.Errorf("refusing to unlock %s: its real name is missing from %s", relPath, vault.NamesPath())
+	}
+	if _, err := os.Lstat(project.AbsPath(name)); err == nil {
+		return fmt.Errorf("refusing to unlock %s: %s already exists", relPath, name)
+	}
+	return nil
+}
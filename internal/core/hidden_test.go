@@ -0,0 +1,168 @@
+package core
+
+import (
+	"os"
+	"path"
+	"strings"
+	"testing"
+)
+
+func TestLockHidden(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "docs/acquisition-targets.xlsx"
+	writeTree(t, p.Root, map[string]string{relPath: "secret"})
+
+	if err := LockFileAs(p, v, m, relPath, LockOptions{HideName: true}); err != nil {
+		t.Fatalf("LockFileAs failed: %v", err)
+	}
+	key, err := ManagedPath(p, v, m, p.AbsPath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := m.Files[key]
+	if entry == nil || !entry.Hidden || path.Dir(key) != "docs" || !strings.HasPrefix(path.Base(key), HiddenPrefix) {
+		t.Fatalf("expected a hidden entry under a generic name, got %q: %+v", key, entry)
+	}
+	if fileExists(p.AbsPath(relPath)) || fileExists(v.FilePath(relPath)) {
+		t.Fatal("expected the real name gone from the project and vault")
+	}
+	if v.HiddenName(key) != relPath {
+		t.Fatalf("expected names file to map %s to %s", key, relPath)
+	}
+	if placeholder, _ := os.ReadFile(p.AbsPath(key)); strings.Contains(string(placeholder), "acquisition") {
+		t.Fatalf("placeholder reveals the name:\n%s", placeholder)
+	}
+	if status := FileStatus(p, v, entry, key, nil); status != "locked" {
+		t.Fatalf("expected status locked, got %s", status)
+	}
+
+	if err := UnlockFileAs(p, v, m, key, UnlockModeCopy, false); err == nil {
+		t.Fatal("expected a copy-mode unlock to be refused")
+	}
+	if err := UnlockFile(p, v, m, key); err != nil {
+		t.Fatalf("UnlockFile failed: %v", err)
+	}
+	if target, err := os.Readlink(p.AbsPath(relPath)); err != nil || target != v.FilePath(key) {
+		t.Fatalf("expected a symlink at the real path, got %q, %v", target, err)
+	}
+	if fileExists(p.AbsPath(key)) {
+		t.Fatal("expected the placeholder removed while unlocked")
+	}
+	writeTree(t, p.Root, map[string]string{relPath: "edited"})
+	if status := FileStatus(p, v, m.Files[key], key, nil); status != "dirty" {
+		t.Fatalf("expected status dirty, got %s", status)
+	}
+
+	if err := LockFile(p, v, m, key, false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+	if _, err := os.Lstat(p.AbsPath(relPath)); err == nil {
+		t.Fatal("expected the symlink removed on re-lock")
+	}
+	if report := VerifyProject(p, v, m); len(report.Issues) != 0 {
+		t.Fatalf("expected no verify issues, got %+v", report.Issues)
+	}
+
+	if err := ForgetFile(p, v, m, key); err != nil {
+		t.Fatalf("ForgetFile failed: %v", err)
+	}
+	if got, err := os.ReadFile(p.AbsPath(relPath)); err != nil || string(got) != "edited" {
+		t.Fatalf("expected the file restored under its real name, got %q, %v", got, err)
+	}
+	if fileExists(p.AbsPath(key)) || v.HiddenName(key) != "" {
+		t.Fatal("expected placeholder and names entry removed")
+	}
+}
+
+func TestRecoverHiddenLock(t *testing.T) {
+	for _, placed := range []bool{false, true} {
+		p, v, m, cleanup := setupLockFileTest(t)
+		relPath := "targets.xlsx"
+		writeTree(t, p.Root, map[string]string{relPath: "secret"})
+		absPath := p.AbsPath(relPath)
+
+		// Replay lockHidden up to (and, if placed, including) writing the placeholder
+		key := newHiddenKey(p, m, relPath)
+		if err := v.updateNames(func(names map[string]string) { names[key] = relPath }); err != nil {
+			t.Fatal(err)
+		}
+		hash, _ := HashFile(absPath)
+		if err := p.journal("lock", key, "begin", ""); err != nil {
+			t.Fatal(err)
+		}
+		for _, dst := range []string{v.FilePath(key), v.BackupPath(key)} {
+			if err := copyFile(absPath, dst); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := p.journal("lock", key, "stored", hash); err != nil {
+			t.Fatal(err)
+		}
+		if placed {
+			if err := os.WriteFile(p.AbsPath(key), GeneratePlaceholder(key), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := RecoverJournal(p, v, m); err != nil {
+			t.Fatalf("placed=%v: RecoverJournal failed: %v", placed, err)
+		}
+		entry, ok := m.Files[key]
+		if placed {
+			if !ok || !entry.Hidden || entry.State != "locked" || entry.Hash != hash {
+				t.Errorf("expected rolled forward to a hidden lock, got %+v", entry)
+			}
+			if fileExists(absPath) {
+				t.Error("expected the original removed from its real path")
+			}
+		} else {
+			if ok || fileExists(v.FilePath(key)) || v.HiddenName(key) != "" {
+				t.Error("expected rolled back with vault copies and name removed")
+			}
+			if got, _ := os.ReadFile(absPath); string(got) != "secret" {
+				t.Errorf("expected the original kept, got %q", got)
+			}
+		}
+		cleanup()
+	}
+}
+
+func TestHiddenNamesSealedInEncryptedVault(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+	setupEncryptedVault(t, v)
+
+	relPath := "docs/acquisition-targets.xlsx"
+	writeTree(t, p.Root, map[string]string{relPath: "secret"})
+	if err := LockFileAs(p, v, m, relPath, LockOptions{HideName: true}); err != nil {
+		t.Fatalf("LockFileAs failed: %v", err)
+	}
+
+	data, err := os.ReadFile(v.NamesPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasEncryptedMagic(v.NamesPath()) || strings.Contains(string(data), "acquisition") {
+		t.Fatalf("expected the names file sealed, got:\n%s", data)
+	}
+	key, err := ManagedPath(p, v, m, p.AbsPath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.HiddenName(key) != relPath {
+		t.Fatalf("expected names file to map %s to %s", key, relPath)
+	}
+
+	sealed := &Vault{UID: v.UID, Dir: v.Dir, Encryption: v.Encryption}
+	if _, err := sealed.HiddenNames(); err == nil {
+		t.Fatal("expected the names unreadable without the passphrase")
+	}
+}
@@ -0,0 +1,322 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/natefinch/atomic"
+)
+
+// History represents ~/.ignlnk/vault/<uid>.history/history.json
+type History struct {
+	Version int                    `json:"version"`
+	Files   map[string][]*Revision `json:"files"` // relPath -> revisions, oldest first
+}
+
+// Revision is one captured version of a vault file. Content lives in
+// objects/<hex>, named by the plaintext hash and stored like a vault file
+// (encrypted if the vault is).
+type Revision struct {
+	Hash       string `json:"hash"`       // "sha256:<hex>" of plaintext
+	Size       int64  `json:"size"`       // Plaintext size in bytes
+	CapturedAt string `json:"capturedAt"` // ISO 8601 timestamp
+	Reason     string `json:"reason"`     // "lock", "relock", "dirty" or "restore"
+}
+
+// HistoryDir returns the path to the revision store (~/.ignlnk/vault/<uid>.history/).
+func (v *Vault) HistoryDir() string {
+	return filepath.Join(filepath.Dir(v.Dir), v.UID+".history")
+}
+
+func (v *Vault) objectsDir() string {
+	return filepath.Join(v.HistoryDir(), "objects")
+}
+
+func (v *Vault) objectPath(hash string) string {
+	return filepath.Join(v.objectsDir(), strings.TrimPrefix(hash, "sha256:"))
+}
+
+// LoadHistory reads the revision log. Returns an empty history if none exists.
+func (v *Vault) LoadHistory() (*History, error) {
+	data, err := os.ReadFile(filepath.Join(v.HistoryDir(), "history.json"))
+	if os.IsNotExist(err) {
+		return &History{Version: 1, Files: make(map[string][]*Revision)}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading history: %w", err)
+	}
+	var h History
+	if err := json.Unmarshal(data, &h); err != nil {
+		return nil, fmt.Errorf("parsing history: %w", err)
+	}
+	if h.Files == nil {
+		h.Files = make(map[string][]*Revision)
+	}
+	return &h, nil
+}
+
+func (v *Vault) saveHistory(h *History) error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling history: %w", err)
+	}
+	data = append(data, '\n')
+	r := strings.NewReader(string(data))
+	if err := atomic.WriteFile(filepath.Join(v.HistoryDir(), "history.json"), r); err != nil {
+		return fmt.Errorf("writing history: %w", err)
+	}
+	return nil
+}
+
+// lockHistory acquires an exclusive file lock on history.lock. Read-only commands
+// (status) capture revisions without holding the manifest lock, so history has its own.
+func (v *Vault) lockHistory() (unlock func(), err error) {
+	if err := os.MkdirAll(v.HistoryDir(), 0o755); err != nil {
+		return nil, fmt.Errorf("creating history directory: %w", err)
+	}
+	fl := flock.New(filepath.Join(v.HistoryDir(), "history.lock"))
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ok, err := fl.TryLockContext(ctx, 250*time.Millisecond)
+	if err != nil {
+		return nil, fmt.Errorf("acquiring history lock: %w", err)
+	}
+	if !ok {
+		return nil, fmt.Errorf("could not acquire history lock — another ignlnk operation may be running")
+	}
+	return func() { fl.Unlock() }, nil
+}
+
+// updateHistory runs fn on the loaded history under the history lock and saves the result.
+func (v *Vault) updateHistory(fn func(h *History) error) error {
+	unlock, err := v.lockHistory()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	h, err := v.LoadHistory()
+	if err != nil {
+		return err
+	}
+	if err := fn(h); err != nil {
+		return err
+	}
+	return v.saveHistory(h)
+}
+
+// CaptureRevision records the current content of a managed file in its history:
+// the vault file when locked, or what the unlocked symlink points at. Directories have
+// no history, so this is a no-op for them.
+func CaptureRevision(project *Project, vault *Vault, entry *FileEntry, relPath, reason string) error {
+	if entry.Dir {
+		return nil
+	}
+	src := vault.FilePath(relPath)
+	if entry.Unlocked() {
+		src = liveCopy(project, vault, entry, relPath)
+	}
+	return captureRevision(project, vault, relPath, src, reason)
+}
+
+// captureRevision stores the content at src as the newest revision of relPath.
+// src may be plaintext or a sealed vault file. No-op if history is disabled or
+// the content matches the newest revision.
+func captureRevision(project *Project, vault *Vault, relPath, src, reason string) error {
+	cfg, err := project.Config()
+	if err != nil {
+		return err
+	}
+	keep := cfg.RevisionsToKeep()
+	if keep == 0 {
+		return nil
+	}
+
+	hash, size, err := vault.statStored(src)
+	if err != nil {
+		return err
+	}
+
+	return vault.updateHistory(func(h *History) error {
+		revs := h.Files[relPath]
+		if n := len(revs); n > 0 && revs[n-1].Hash == hash {
+			return nil
+		}
+
+		obj := vault.objectPath(hash)
+		if _, err := os.Stat(obj); os.IsNotExist(err) {
+			if err := os.MkdirAll(filepath.Dir(obj), 0o755); err != nil {
+				return fmt.Errorf("creating history objects directory: %w", err)
+			}
+			// Sealed vault files are copied as-is; plaintext is stored (encrypted if the vault is).
+			if vault.isSealedFile(src) {
+				err = replaceFile(src, obj)
+			} else {
+				err = vault.storeFile(src, obj)
+			}
+			if err != nil {
+				os.Remove(obj)
+				return fmt.Errorf("storing revision: %w", err)
+			}
+		}
+
+		h.Files[relPath] = append(revs, &Revision{
+			Hash:       hash,
+			Size:       size,
+			CapturedAt: time.Now().UTC().Format(time.RFC3339),
+			Reason:     reason,
+		})
+		if len(h.Files[relPath]) > keep {
+			h.Files[relPath] = h.Files[relPath][len(h.Files[relPath])-keep:]
+		}
+		vault.pruneObjects(h)
+		return nil
+	})
+}
+
+// FindRevision resolves a version spec against revs (oldest first). A number n
+// selects the n-th newest revision (1 = newest); otherwise spec is a hash or hash prefix.
+func FindRevision(revs []*Revision, spec string) (*Revision, error) {
+	if n, err := strconv.Atoi(spec); err == nil {
+		if n < 1 || n > len(revs) {
+			return nil, fmt.Errorf("version %d out of range (1-%d)", n, len(revs))
+		}
+		return revs[len(revs)-n], nil
+	}
+
+	prefix := strings.TrimPrefix(spec, "sha256:")
+	var found *Revision
+	for _, rev := range revs {
+		if strings.HasPrefix(strings.TrimPrefix(rev.Hash, "sha256:"), prefix) {
+			if found != nil && found.Hash != rev.Hash {
+				return nil, fmt.Errorf("ambiguous version %q", spec)
+			}
+			found = rev
+		}
+	}
+	if found == nil {
+		return nil, fmt.Errorf("no revision matches %q", spec)
+	}
+	return found, nil
+}
+
+// RestoreRevision replaces the vault content of relPath with a recorded revision.
+// The current content is captured first so the restore itself can be undone.
+// Updates entry.Hash (in-memory; caller saves).
+func RestoreRevision(project *Project, vault *Vault, manifest *Manifest, relPath, spec string) (*Revision, error) {
+	entry, ok := manifest.Files[relPath]
+	if !ok {
+		return nil, fmt.Errorf("file not managed: %s", relPath)
+	}
+	if entry.Dir {
+		return nil, fmt.Errorf("%s: history is %w", relPath, ErrDirUnsupported)
+	}
+	if err := vault.requireKey(); err != nil {
+		return nil, err
+	}
+
+	if err := CaptureRevision(project, vault, entry, relPath, "restore"); err != nil {
+		return nil, fmt.Errorf("capturing current content: %w", err)
+	}
+
+	var restored *Revision
+	err := vault.updateHistory(func(h *History) error {
+		rev, err := FindRevision(h.Files[relPath], spec)
+		if err != nil {
+			return err
+		}
+		obj := vault.objectPath(rev.Hash)
+		if hash, err := vault.hashStored(obj); err != nil || hash != rev.Hash {
+			return fmt.Errorf("revision %s is missing or corrupted", rev.Hash)
+		}
+
+		if err := project.journal("restore", relPath, "begin", rev.Hash); err != nil {
+			return err
+		}
+
+		// Objects share the vault's storage format, so they copy straight in.
+		vaultPath := vault.FilePath(relPath)
+		if err := replaceFile(obj, vaultPath); err != nil {
+			return fmt.Errorf("restoring vault file: %w", err)
+		}
+		if err := replaceFile(obj, vault.BackupPath(relPath)); err != nil {
+			return fmt.Errorf("restoring backup: %w", err)
+		}
+		// Refresh a decrypted working copy or project copy, or it would read as edits
+		if err := refreshLiveCopy(project, vault, entry, relPath, obj); err != nil {
+			return fmt.Errorf("restoring unlocked copy: %w", err)
+		}
+		restored = rev
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entry.Hash = restored.Hash
+	return restored, nil
+}
+
+// refreshLiveCopy rewrites an unlocked file's separate live copy (see liveCopy) from the
+// vault-format file src, and re-applies a read-only unlock to the replaced file. No-op
+// when locked.
+func refreshLiveCopy(project *Project, vault *Vault, entry *FileEntry, relPath, src string) error {
+	if !entry.Unlocked() {
+		return nil
+	}
+	dst := liveCopy(project, vault, entry, relPath)
+	if dst != vault.FilePath(relPath) {
+		tmp := dst + tempSuffix
+		if err := vault.restoreFile(src, tmp); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+		if err := os.Rename(tmp, dst); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if entry.ReadOnly {
+		_, err := makeReadOnly(dst)
+		return err
+	}
+	return nil
+}
+
+// dropHistory removes all revisions of relPath and their unreferenced objects.
+func dropHistory(vault *Vault, relPath string) error {
+	if _, err := os.Stat(vault.HistoryDir()); os.IsNotExist(err) {
+		return nil
+	}
+	return vault.updateHistory(func(h *History) error {
+		delete(h.Files, relPath)
+		vault.pruneObjects(h)
+		return nil
+	})
+}
+
+// pruneObjects deletes objects no longer referenced by any revision.
+func (v *Vault) pruneObjects(h *History) {
+	referenced := make(map[string]bool)
+	for _, revs := range h.Files {
+		for _, rev := range revs {
+			referenced[strings.TrimPrefix(rev.Hash, "sha256:")] = true
+		}
+	}
+	entries, err := os.ReadDir(v.objectsDir())
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !referenced[e.Name()] {
+			os.Remove(filepath.Join(v.objectsDir(), e.Name()))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHistoryCapturedOnLockAndRelock(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "config/secret.txt"
+	absPath := p.AbsPath(relPath)
+	if err := os.MkdirAll(filepath.Dir(absPath), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(absPath, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	if err := UnlockFile(p, v, m, relPath); err != nil {
+		t.Fatalf("UnlockFile failed: %v", err)
+	}
+	if err := os.WriteFile(absPath, []byte("v2 bad edit"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("re-lock failed: %v", err)
+	}
+
+	h, err := v.LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revs := h.Files[relPath]
+	if len(revs) != 2 || revs[0].Reason != "lock" || revs[1].Reason != "relock" {
+		t.Fatalf("expected lock and relock revisions, got %+v", revs)
+	}
+
+	// Version 2 = second newest = original content
+	rev, err := RestoreRevision(p, v, m, relPath, "2")
+	if err != nil {
+		t.Fatalf("RestoreRevision failed: %v", err)
+	}
+	got, err := os.ReadFile(v.FilePath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "v1" {
+		t.Fatalf("expected vault content v1, got %q", got)
+	}
+	if m.Files[relPath].Hash != rev.Hash {
+		t.Fatalf("expected manifest hash %s, got %s", rev.Hash, m.Files[relPath].Hash)
+	}
+}
+
+func TestHistoryRetention(t *testing.T) {
+	p, v, _, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	keep := 2
+	p.config = &Config{HistoryKeep: &keep}
+
+	relPath := "secret.txt"
+	vaultPath := v.FilePath(relPath)
+	for _, content := range []string{"a", "b", "c"} {
+		if err := os.WriteFile(vaultPath, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := captureRevision(p, v, relPath, vaultPath, "dirty"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	h, err := v.LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(h.Files[relPath]); n != keep {
+		t.Fatalf("expected %d revisions, got %d", keep, n)
+	}
+	objects, err := os.ReadDir(v.objectsDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != keep {
+		t.Fatalf("expected %d objects after pruning, got %d", keep, len(objects))
+	}
+}
+
+func TestFindRevisionByHashPrefix(t *testing.T) {
+	revs := []*Revision{
+		{Hash: "sha256:aaaa1111"},
+		{Hash: "sha256:aaaa2222"},
+		{Hash: "sha256:bbbb3333"},
+	}
+	if rev, err := FindRevision(revs, "bbbb"); err != nil || rev != revs[2] {
+		t.Fatalf("expected unique prefix match, got %v, %v", rev, err)
+	}
+	if _, err := FindRevision(revs, "aaaa"); err == nil {
+		t.Fatal("expected ambiguous prefix error")
+	}
+	if rev, err := FindRevision(revs, "1"); err != nil || rev != revs[2] {
+		t.Fatalf("expected version 1 to be newest, got %v, %v", rev, err)
+	}
+	if _, err := FindRevision(revs, "4"); err == nil {
+		t.Fatal("expected out of range error")
+	}
+}
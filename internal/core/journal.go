@@ -0,0 +1,439 @@
+package core
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/natefinch/atomic"
+)
+
+// JournalRecord is one line of .ignlnk/journal.jsonl, the write-ahead log of file
+// operations whose manifest update has not been saved yet. SaveManifest clears it.
+type JournalRecord struct {
+	Op   string `json:"op"`             // "lock", "relock", "unlock", "forget", "commit" or "restore"
+	Path string `json:"path"`           // Manifest relative path
+	Step string `json:"step"`           // "begin", then op-specific steps
+	Hash string `json:"hash,omitempty"` // Content hash the op is committing to, when known
+	Time string `json:"time"`           // ISO 8601 timestamp
+}
+
+func (p *Project) journalPath() string {
+	return filepath.Join(p.IgnlnkDir, "journal.jsonl")
+}
+
+// journal appends a record and syncs it to disk before the step it describes runs.
+func (p *Project) journal(op, relPath, step, hash string) error {
+	p.journalMu.Lock()
+	defer p.journalMu.Unlock()
+
+	data, err := json.Marshal(&JournalRecord{
+		Op:   op,
+		Path: relPath,
+		Step: step,
+		Hash: hash,
+		Time: time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return fmt.Errorf("marshaling journal record: %w", err)
+	}
+	f, err := os.OpenFile(p.journalPath(), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("opening journal: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("writing journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("syncing journal: %w", err)
+	}
+	return nil
+}
+
+// journalStep records progress within an operation. Step records are advisory —
+// recovery decides from what is on disk — so a failed append does not fail the operation.
+func (p *Project) journalStep(op, relPath, step, hash string) {
+	p.journal(op, relPath, step, hash)
+}
+
+// LoadJournal reads pending journal records. A torn final line (crash mid-append) is ignored.
+func (p *Project) LoadJournal() ([]*JournalRecord, error) {
+	f, err := os.Open(p.journalPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("opening journal: %w", err)
+	}
+	defer f.Close()
+
+	var records []*JournalRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec JournalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		records = append(records, &rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading journal: %w", err)
+	}
+	return records, nil
+}
+
+// DiscardJournal drops pending records without recovering them.
+func (p *Project) DiscardJournal() error {
+	return p.clearJournal()
+}
+
+// clearJournal discards all records; called once the manifest reflecting them is saved.
+func (p *Project) clearJournal() error {
+	if err := os.Remove(p.journalPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("clearing journal: %w", err)
+	}
+	return nil
+}
+
+// journalOp is one operation reconstructed from the journal: its begin record plus the last step reached.
+type journalOp struct {
+	op, path, hash string
+	steps          map[string]string // step -> hash recorded with it
+}
+
+func (o *journalOp) reached(step string) bool {
+	_, ok := o.steps[step]
+	return ok
+}
+
+// placeholderTemplated is the journal step recording the hash of an ordinary placeholder
+// rendered from a project template or with a note, unlike the default one.
+const placeholderTemplated = "templated"
+
+// placeholderStep is the journal step recording the hash of a placeholder in mode.
+func placeholderStep(mode string) string {
+	if mode == "" {
+		return placeholderTemplated
+	}
+	return mode
+}
+
+// placeholder returns the mode and hash of the non-default placeholder the op journaled
+// before writing it, if any.
+func (o *journalOp) placeholder() (mode, hash string) {
+	for _, mode := range []string{PlaceholderRedacted, PlaceholderDecoy, PlaceholderRegions, ""} {
+		if hash, ok := o.steps[placeholderStep(mode)]; ok {
+			return mode, hash
+		}
+	}
+	return "", ""
+}
+
+// RecoverJournal reconciles operations interrupted before their manifest update was saved
+// (crash, kill -9, power loss), rolling each forward or back based on what is on disk.
+// Modifies manifest in memory; the caller saves it, which clears the journal.
+// Returns a description of each recovered operation.
+func RecoverJournal(project *Project, vault *Vault, manifest *Manifest) ([]string, error) {
+	records, err := project.LoadJournal()
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+	if err := vault.requireKey(); err != nil {
+		return nil, fmt.Errorf("recovering interrupted operations: %w", err)
+	}
+
+	var ops []*journalOp
+	current := make(map[string]*journalOp)
+	for _, rec := range records {
+		if rec.Step == "begin" {
+			op := &journalOp{op: rec.Op, path: rec.Path, hash: rec.Hash, steps: make(map[string]string)}
+			ops = append(ops, op)
+			current[rec.Path] = op
+			continue
+		}
+		if op, ok := current[rec.Path]; ok && op.op == rec.Op {
+			op.steps[rec.Step] = rec.Hash
+		}
+	}
+
+	var actions []string
+	for _, op := range ops {
+		action, err := recoverOp(project, vault, manifest, op)
+		if err != nil {
+			return actions, fmt.Errorf("recovering %s of %s: %w", op.op, op.path, err)
+		}
+		if action != "" {
+			actions = append(actions, fmt.Sprintf("%s %s: %s", op.op, filepath.FromSlash(op.path), action))
+		}
+	}
+	return actions, nil
+}
+
+func recoverOp(project *Project, vault *Vault, manifest *Manifest, op *journalOp) (string, error) {
+	relPath := op.path
+	absPath := project.AbsPath(relPath)
+	vaultPath := vault.FilePath(relPath)
+	entry := manifest.Files[relPath]
+	if isDirOp(vault, op, entry) {
+		return recoverDirOp(project, vault, manifest, op)
+	}
+	// A non-default placeholder is only recognizable by its hash: the journaled one if
+	// the op got as far as writing it, else the one recorded at the last lock
+	placeholderMode, placeholderHash := op.placeholder()
+	if placeholderHash == "" && entry != nil {
+		placeholderHash = entry.PlaceholderHash
+	}
+	kind := pathKind(project, absPath, relPath, entry, placeholderHash)
+	// A hidden file is only at its placeholder path while locked; otherwise (and before
+	// a new lock wrote the placeholder) it is at its real path
+	hiddenName := vault.HiddenName(relPath)
+	origPath := absPath
+	if hiddenName != "" && (entry == nil || entry.Hidden) {
+		origPath = project.AbsPath(hiddenName)
+		if kind == "missing" {
+			kind = pathKind(project, origPath, relPath, nil, "")
+		}
+	} else {
+		hiddenName = ""
+	}
+
+	switch op.op {
+	case "lock":
+		if entry != nil {
+			return "", nil
+		}
+		// The original is hashed while it is copied, so the hash arrives with the "stored" step
+		hash := op.steps["stored"]
+		switch kind {
+		case "file":
+			if !fileExists(vaultPath) && !fileExists(vault.BackupPath(relPath)) {
+				if hiddenName != "" {
+					vault.forgetHiddenName(relPath)
+				}
+				return "", nil // Aborted before anything was stored
+			}
+		case "placeholder":
+			if hash == "" {
+				return "", fmt.Errorf("placeholder written but no stored hash was journaled")
+			}
+			if !storedMatches(vault, vaultPath, hash) {
+				if !storedMatches(vault, vault.BackupPath(relPath), hash) {
+					return "", fmt.Errorf("placeholder written but no vault copy matches %s", hash)
+				}
+				if err := replaceFile(vault.BackupPath(relPath), vaultPath); err != nil {
+					return "", err
+				}
+			}
+			if !storedMatches(vault, vault.BackupPath(relPath), hash) {
+				if err := os.MkdirAll(filepath.Dir(vault.BackupPath(relPath)), 0o755); err != nil {
+					return "", err
+				}
+				if err := replaceFile(vaultPath, vault.BackupPath(relPath)); err != nil {
+					return "", err
+				}
+			}
+			manifest.Files[relPath] = &FileEntry{
+				State:           "locked",
+				LockedAt:        time.Now().UTC().Format(time.RFC3339),
+				Hash:            hash,
+				Placeholder:     placeholderMode,
+				PlaceholderHash: placeholderHash,
+				Canary:          op.steps["canary"],
+				Hidden:          hiddenName != "",
+			}
+			if hiddenName != "" && sameContent(vault, origPath, vaultPath) {
+				os.Remove(origPath)
+			}
+			return "rolled forward (locked)", nil
+		case "missing":
+			if !storedMatches(vault, vaultPath, hash) {
+				return "", fmt.Errorf("original missing and no vault copy matches %s", hash)
+			}
+			if err := vault.restoreFile(vaultPath, origPath); err != nil {
+				return "", err
+			}
+		}
+		// Original still in place (or just restored): discard partial vault copies
+		removeVaultCopies(vault, relPath)
+		if hiddenName != "" {
+			vault.forgetHiddenName(relPath)
+		}
+		return "rolled back (original kept)", nil
+
+	case "relock", "unlock":
+		if entry == nil {
+			return "", nil
+		}
+		recoverCommittedHash(vault, entry, relPath)
+		switch kind {
+		case "placeholder":
+			removeWorkCopy(vault, relPath)
+			if placeholderHash != "" && entry.PlaceholderHash != placeholderHash {
+				entry.Placeholder, entry.PlaceholderHash = placeholderMode, placeholderHash
+				if canary, ok := op.steps["canary"]; ok {
+					entry.Canary = canary
+				}
+				if entry.State == "locked" {
+					return "placeholder replaced (" + placeholderStep(placeholderMode) + ")", nil
+				}
+			}
+			if entry.State == "locked" {
+				return "", nil
+			}
+			entry.State = "locked"
+			entry.LockedAt = time.Now().UTC().Format(time.RFC3339)
+			return "locked", nil
+		case "symlink":
+			if entry.State == "unlocked" {
+				return "", nil
+			}
+			entry.State = "unlocked"
+			return "unlocked", nil
+		case "missing":
+			// Interrupted between removing one form and creating the other: lock, which
+			// is the safe state. Seal any working-copy edits first.
+			if _, err := commitVaultCopy(vault, entry, relPath, vault.WorkPath(relPath)); err != nil {
+				return "", err
+			}
+			entry.LockedAt = time.Now().UTC().Format(time.RFC3339)
+			// The ordinary placeholder; the next re-lock redacts again
+			ordinary := *entry
+			ordinary.Placeholder = ""
+			placeholder, placeholderHash, err := lockPlaceholder(project, vault, relPath, &ordinary)
+			if err != nil {
+				return "", err
+			}
+			if err := atomic.WriteFile(absPath, strings.NewReader(string(placeholder))); err != nil {
+				return "", fmt.Errorf("writing placeholder: %w", err)
+			}
+			removeWorkCopy(vault, relPath)
+			entry.State = "locked"
+			entry.PlaceholderHash = placeholderHash
+			return "placeholder rewritten (locked)", nil
+		case "file":
+			// A copy-mode unlock renames the plaintext over the placeholder in one step
+			if entry.State == "unlocked-copy" {
+				return "", nil
+			}
+			if op.op == "unlock" && hiddenName == "" && sameContent(vault, absPath, vaultPath) {
+				entry.State = "unlocked-copy"
+				return "unlocked (copy)", nil
+			}
+		}
+		return "left as is: path holds unexpected data — inspect it, then run 'ignlnk verify'", nil
+
+	case "forget":
+		if entry == nil {
+			return "", nil
+		}
+		if kind == "placeholder" || kind == "symlink" {
+			return "", nil // Nothing was removed yet; the file stays managed
+		}
+		// The placeholder/symlink is gone: finish restoring the original.
+		if !op.reached("restored") || kind == "missing" {
+			source := vaultPath
+			if workPath := vault.WorkPath(relPath); workPath != vaultPath && fileExists(workPath) {
+				source = workPath
+			}
+			if !fileExists(source) {
+				return "", fmt.Errorf("original not restored and vault copy missing")
+			}
+			tmp := origPath + tempSuffix
+			if err := vault.restoreFile(source, tmp); err != nil {
+				os.Remove(tmp)
+				return "", err
+			}
+			if err := os.Rename(tmp, origPath); err != nil {
+				os.Remove(tmp)
+				return "", err
+			}
+		}
+		removeVaultCopies(vault, relPath)
+		removeWorkCopy(vault, relPath)
+		dropHistory(vault, relPath)
+		if hiddenName != "" {
+			vault.forgetHiddenName(relPath)
+		}
+		delete(manifest.Files, relPath)
+		return "rolled forward (forgotten)", nil
+
+	case "commit":
+		if entry == nil || !recoverCommittedHash(vault, entry, relPath) {
+			return "", nil
+		}
+		return "committed content kept", nil
+
+	case "restore":
+		if entry == nil || op.hash == entry.Hash || !storedMatches(vault, vaultPath, op.hash) {
+			return "", nil // Vault copy was not replaced yet
+		}
+		// Vault copy already holds the revision: finish by restoring the backup
+		backupPath := vault.BackupPath(relPath)
+		if !storedMatches(vault, backupPath, op.hash) {
+			if err := replaceFile(vaultPath, backupPath); err != nil {
+				return "", fmt.Errorf("restoring backup: %w", err)
+			}
+		}
+		if err := refreshLiveCopy(project, vault, entry, relPath, vaultPath); err != nil {
+			return "", fmt.Errorf("restoring unlocked copy: %w", err)
+		}
+		entry.Hash = op.hash
+		return "rolled forward (revision restored)", nil
+	}
+	return "", nil
+}
+
+// recoverCommittedHash detects a commit whose backup rotation finished but whose manifest
+// hash was never saved: vault copy and backup agree on content other than entry.Hash.
+// Uncommitted plaintext edits never match, as the backup still holds the old content.
+func recoverCommittedHash(vault *Vault, entry *FileEntry, relPath string) bool {
+	hash, err := vault.hashStored(vault.FilePath(relPath))
+	if err != nil || hash == entry.Hash || !storedMatches(vault, vault.BackupPath(relPath), hash) {
+		return false
+	}
+	entry.Hash = hash
+	return true
+}
+
+// pathKind classifies what is at a managed file's project path, recognizing the
+// placeholder of entry (nil if not yet in the manifest) or one hashing to placeholderHash.
+func pathKind(project *Project, absPath, relPath string, entry *FileEntry, placeholderHash string) string {
+	probe := FileEntry{}
+	if entry != nil {
+		probe = *entry
+	}
+	probe.PlaceholderHash = placeholderHash
+	info, err := os.Lstat(absPath)
+	switch {
+	case err != nil:
+		return "missing"
+	case info.Mode()&os.ModeSymlink != 0:
+		return "symlink"
+	case info.Mode().IsRegular() && project.isPlaceholderAt(absPath, relPath, &probe, info.Size()):
+		return "placeholder"
+	case info.Mode().IsRegular():
+		return "file"
+	}
+	return "other"
+}
+
+// sameContent reports whether plaintext file path matches the vault-format file stored.
+func sameContent(vault *Vault, path, stored string) bool {
+	hash, err := HashFile(path)
+	return err == nil && storedMatches(vault, stored, hash)
+}
+
+// removeVaultCopies deletes the vault copy and backup of relPath and their empty parents.
+func removeVaultCopies(vault *Vault, relPath string) {
+	vaultPath := vault.FilePath(relPath)
+	os.Remove(vaultPath)
+	removeEmptyParents(filepath.Dir(vaultPath), vault.Dir)
+	backupPath := vault.BackupPath(relPath)
+	os.Remove(backupPath)
+	removeEmptyParents(filepath.Dir(backupPath), vault.BackupDir())
+}
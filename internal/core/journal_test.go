@@ -0,0 +1,125 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// crashAfterLockPlaceholder replays LockFile up to the placeholder write, as if the
+// process died before the manifest entry was added and saved.
+func crashAfterLockPlaceholder(t *testing.T, p *Project, v *Vault, relPath string, writePlaceholder bool) string {
+	t.Helper()
+	absPath := p.AbsPath(relPath)
+	hash, err := HashFile(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.journal("lock", relPath, "begin", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, dst := range []string{v.FilePath(relPath), v.BackupPath(relPath)} {
+		if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := copyFile(absPath, dst); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := p.journal("lock", relPath, "stored", hash); err != nil {
+		t.Fatal(err)
+	}
+	if writePlaceholder {
+		if err := os.WriteFile(absPath, GeneratePlaceholder(relPath), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return hash
+}
+
+func TestRecoverLockRollsForward(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	if err := os.WriteFile(p.AbsPath(relPath), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	hash := crashAfterLockPlaceholder(t, p, v, relPath, true)
+
+	actions, err := RecoverJournal(p, v, m)
+	if err != nil {
+		t.Fatalf("RecoverJournal failed: %v", err)
+	}
+	if len(actions) != 1 {
+		t.Fatalf("expected 1 recovered operation, got %v", actions)
+	}
+	entry, ok := m.Files[relPath]
+	if !ok || entry.State != "locked" || entry.Hash != hash {
+		t.Fatalf("expected locked entry with hash %s, got %+v", hash, entry)
+	}
+}
+
+func TestRecoverLockRollsBack(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	if err := os.WriteFile(p.AbsPath(relPath), []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	crashAfterLockPlaceholder(t, p, v, relPath, false)
+
+	if _, err := RecoverJournal(p, v, m); err != nil {
+		t.Fatalf("RecoverJournal failed: %v", err)
+	}
+	if _, ok := m.Files[relPath]; ok {
+		t.Fatal("expected no manifest entry after rollback")
+	}
+	if _, err := os.Stat(v.FilePath(relPath)); !os.IsNotExist(err) {
+		t.Fatal("expected partial vault copy to be removed")
+	}
+	got, err := os.ReadFile(p.AbsPath(relPath))
+	if err != nil || string(got) != "secret" {
+		t.Fatalf("expected original kept, got %q, %v", got, err)
+	}
+}
+
+func TestRecoverForgetRollsForward(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	if err := os.WriteFile(absPath, []byte("secret"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	if err := p.clearJournal(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Crash after removing the placeholder, before the original was restored
+	if err := p.journal("forget", relPath, "begin", m.Files[relPath].Hash); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(absPath); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := RecoverJournal(p, v, m); err != nil {
+		t.Fatalf("RecoverJournal failed: %v", err)
+	}
+	if _, ok := m.Files[relPath]; ok {
+		t.Fatal("expected manifest entry removed")
+	}
+	got, err := os.ReadFile(absPath)
+	if err != nil || string(got) != "secret" {
+		t.Fatalf("expected original restored, got %q, %v", got, err)
+	}
+	if _, err := os.Stat(v.FilePath(relPath)); !os.IsNotExist(err) {
+		t.Fatal("expected vault copy removed")
+	}
+}
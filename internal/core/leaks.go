@@ -0,0 +1,99 @@
+package core
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// canaryPrefix starts every canary token, followed by canaryHexLen hex characters. The
+// alphabet survives JSON, shell and diff quoting, so a token is found verbatim wherever
+// a placeholder's text was copied.
+const (
+	canaryPrefix = "ignlnk-canary-"
+	canaryHexLen = 20
+)
+
+// Leak is a canary token found outside its placeholder.
+type Leak struct {
+	Path   string `json:"path,omitempty"` // Managed file whose placeholder escaped; "" if the token is unknown
+	Token  string `json:"token"`
+	Source string `json:"source"` // File scanned, or "-" for stdin
+	Line   int    `json:"line"`
+}
+
+// generateCanary returns a new canary token (80 random bits) using crypto/rand.
+func generateCanary() string {
+	b := make([]byte, canaryHexLen/2)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return canaryPrefix + hex.EncodeToString(b)
+}
+
+// canaryFor returns the canary token to embed when locking a file last locked as entry
+// (nil if unmanaged): its existing token if it has one, so leaks found later still match,
+// else a new one. "" if want is false.
+func canaryFor(entry *FileEntry, want bool) string {
+	switch {
+	case !want:
+		return ""
+	case entry != nil && entry.Canary != "":
+		return entry.Canary
+	}
+	return generateCanary()
+}
+
+// CanaryTokens maps each canary token recorded in the manifest to its file's path.
+func CanaryTokens(manifest *Manifest) map[string]string {
+	tokens := make(map[string]string)
+	for relPath, entry := range manifest.Files {
+		if entry.Canary != "" {
+			tokens[entry.Canary] = relPath
+		}
+	}
+	return tokens
+}
+
+// ScanLeaks reads r line by line and reports every canary token in it, once per line,
+// resolving known tokens to their file via tokens (see CanaryTokens). Tokens that are
+// not in the manifest (e.g. of a forgotten file) are reported with an empty Path.
+func ScanLeaks(r io.Reader, source string, tokens map[string]string) ([]Leak, error) {
+	var leaks []Leak
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		seen := make(map[string]bool)
+		for _, token := range findCanaries(line) {
+			if !seen[token] {
+				seen[token] = true
+				leaks = append(leaks, Leak{Path: tokens[token], Token: token, Source: source, Line: n})
+			}
+		}
+		if err == io.EOF {
+			return leaks, nil
+		}
+		if err != nil {
+			return leaks, fmt.Errorf("reading %s: %w", source, err)
+		}
+	}
+}
+
+// findCanaries returns the well-formed canary tokens in line, in order.
+func findCanaries(line string) []string {
+	var found []string
+	for {
+		i := strings.Index(line, canaryPrefix)
+		if i < 0 {
+			return found
+		}
+		line = line[i+len(canaryPrefix):]
+		if len(line) >= canaryHexLen && strings.Trim(line[:canaryHexLen], "0123456789abcdef") == "" {
+			found = append(found, canaryPrefix+line[:canaryHexLen])
+			line = line[canaryHexLen:]
+		}
+	}
+}
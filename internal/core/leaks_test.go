@@ -0,0 +1,87 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestScanLeaks(t *testing.T) {
+	known := canaryPrefix + "0123456789abcdef0123"
+	unknown := canaryPrefix + "ffffffffffffffffffff"
+	tokens := map[string]string{known: "secrets/.env"}
+	input := strings.Join([]string{
+		"nothing here",
+		`{"content": "# Canary: ` + known + `\nKEY=<redacted>", "again": "` + known + `"}`,
+		"short " + canaryPrefix + "0123",
+		"+" + unknown,
+	}, "\n")
+
+	leaks, err := ScanLeaks(strings.NewReader(input), "log.txt", tokens)
+	if err != nil {
+		t.Fatalf("ScanLeaks failed: %v", err)
+	}
+	want := []Leak{
+		{Path: "secrets/.env", Token: known, Source: "log.txt", Line: 2},
+		{Path: "", Token: unknown, Source: "log.txt", Line: 4},
+	}
+	if len(leaks) != len(want) {
+		t.Fatalf("expected %d leaks, got %+v", len(want), leaks)
+	}
+	for i := range want {
+		if leaks[i] != want[i] {
+			t.Errorf("leak %d: expected %+v, got %+v", i, want[i], leaks[i])
+		}
+	}
+}
+
+func TestLockFileCanary(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	if err := os.WriteFile(absPath, []byte("password"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFileAs(p, v, m, relPath, LockOptions{Canary: true}); err != nil {
+		t.Fatalf("LockFileAs failed: %v", err)
+	}
+	token := m.Files[relPath].Canary
+	if !strings.HasPrefix(token, canaryPrefix) {
+		t.Fatalf("expected a canary token recorded, got %+v", m.Files[relPath])
+	}
+	if got := CanaryTokens(m)[token]; got != relPath {
+		t.Fatalf("expected token mapped to %s, got %q", relPath, got)
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), token) {
+		t.Fatalf("placeholder lacks canary token: %q", data)
+	}
+	if status := FileStatus(p, v, m.Files[relPath], relPath, nil); status != "locked" {
+		t.Fatalf("expected locked, got %q", status)
+	}
+
+	// Rewriting the placeholder keeps the token
+	if err := LockFileAs(p, v, m, relPath, LockOptions{Canary: true, Note: "n"}); err != nil {
+		t.Fatalf("LockFileAs failed: %v", err)
+	}
+	if got := m.Files[relPath].Canary; got != token {
+		t.Fatalf("expected token %s kept, got %s", token, got)
+	}
+
+	// Turning it off gives back the default placeholder
+	if err := LockFileAs(p, v, m, relPath, LockOptions{}); err != nil {
+		t.Fatalf("LockFileAs failed: %v", err)
+	}
+	entry := m.Files[relPath]
+	if entry.Canary != "" || entry.PlaceholderHash != "" {
+		t.Fatalf("expected canary removed, got %+v", entry)
+	}
+	if data, err := os.ReadFile(absPath); err != nil || string(data) != string(GeneratePlaceholder(relPath)) {
+		t.Fatalf("expected default placeholder, got %q, %v", data, err)
+	}
+}
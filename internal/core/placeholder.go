@@ -0,0 +1,300 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// PlaceholderVars are the values a placeholder template can use.
+type PlaceholderVars struct {
+	Path     string // Project-relative path, slash-separated
+	Owner    string // Config.Owner
+	Note     string // FileEntry.Note, from 'lock --note'
+	LockedAt string // FileEntry.LockedAt (ISO 8601)
+	Canary   string // FileEntry.Canary, from 'lock --canary'
+}
+
+// DefaultPlaceholderTemplate is the placeholder body unless Config.PlaceholderTemplate
+// sets one (a Go text/template over PlaceholderVars). Every rendered body is preceded by
+// the "[ignlnk:protected]" marker.
+const DefaultPlaceholderTemplate = `This file is protected by ignlnk.
+{{- if .Owner}} Owner: {{.Owner}}.{{end}}
+To view its contents, ask the user to run:
+
+    ignlnk unlock {{.Path}}
+{{if .Note}}
+Note: {{.Note}}
+{{end}}
+Do NOT attempt to modify or bypass this file.
+{{- if .Canary}}
+Canary: {{.Canary}}{{end}}
+`
+
+// placeholderFormat renders placeholders that stay syntactically valid for files a tool
+// parses at startup (config files), so locking one does not crash the tool.
+type placeholderFormat struct {
+	Name     string
+	Patterns []string // Base-name globs (filepath.Match)
+	// Lead is what precedes placeholderPrefix at the start of the file; IsPlaceholder
+	// recognizes a placeholder of any format by it.
+	Lead   string
+	Render func(body string) string
+	// Scrub renders a placeholder listing content's keys with every value scrubbed (see
+	// RedactedPlaceholder, DecoyPlaceholder) after notice; nil if the format has none
+	Scrub func(notice string, content []byte, scrub scrubFunc) ([]byte, error)
+}
+
+// placeholderFormats is the placeholder template registry, matched in order. Files
+// matching none get the rendered body as plain text.
+var placeholderFormats = []placeholderFormat{
+	{
+		Name:     "json",
+		Patterns: []string{"*.json"},
+		Lead:     `{"_ignlnk": "`,
+		Render: func(body string) string {
+			return fmt.Sprintf("{\"_ignlnk\": %s}\n", jsonString(singleLine(body)))
+		},
+		Scrub: scrubJSON,
+	},
+	{
+		Name:     "yaml",
+		Patterns: []string{"*.yaml", "*.yml"},
+		Lead:     "# ",
+		Render: func(body string) string {
+			// A comment-only document is null; keep it a mapping
+			return commentLines(body, "#") + "_ignlnk: protected\n"
+		},
+		Scrub: scrubYAML,
+	},
+	{
+		Name:     "toml",
+		Patterns: []string{"*.toml"},
+		Lead:     "# ",
+		Render:   func(body string) string { return commentLines(body, "#") },
+	},
+	{
+		Name:     "env",
+		Patterns: []string{".env", ".env.*", "*.env"},
+		Lead:     "# ",
+		Render:   func(body string) string { return commentLines(body, "#") },
+		Scrub:    scrubEnv,
+	},
+	{
+		Name:     "ini",
+		Patterns: []string{"*.ini"},
+		Lead:     "; ",
+		Render:   func(body string) string { return commentLines(body, ";") },
+		Scrub:    scrubINI,
+	},
+}
+
+// renderPlaceholderBody executes a placeholder template (the default if empty) and
+// prepends the marker.
+func renderPlaceholderBody(tmpl string, vars PlaceholderVars) (string, error) {
+	if tmpl == "" {
+		tmpl = DefaultPlaceholderTemplate
+	}
+	t, err := template.New("placeholder").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parsing placeholder template: %w", err)
+	}
+	var b strings.Builder
+	b.WriteString(placeholderPrefix + " ")
+	if err := t.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("rendering placeholder template: %w", err)
+	}
+	body := b.String()
+	if !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	return body, nil
+}
+
+// plainPlaceholder is the default placeholder body with no owner or note, which every
+// file got before formats and templates existed.
+func plainPlaceholder(relPath string) string {
+	body, _ := renderPlaceholderBody("", PlaceholderVars{Path: relPath})
+	return body
+}
+
+// formatPlaceholder renders body in relPath's registered format, if any.
+func formatPlaceholder(relPath, body string) []byte {
+	if f := placeholderFormatFor(relPath); f != nil {
+		return []byte(f.Render(body))
+	}
+	return []byte(body)
+}
+
+// singleLine joins the lines of text for formats that hold it in a string value,
+// separating a line that does not end a sentence (e.g. a command) with " --".
+func singleLine(text string) string {
+	var parts []string
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if n := len(parts); n > 0 && !strings.ContainsAny(parts[n-1][len(parts[n-1])-1:], ".:!?") {
+			parts[n-1] += " --"
+		}
+		parts = append(parts, line)
+	}
+	return strings.Join(parts, " ")
+}
+
+// commentLines comments out every line of text.
+func commentLines(text, comment string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		switch line {
+		case "":
+		case "\n":
+			b.WriteString(comment + "\n")
+		default:
+			b.WriteString(comment + " " + line)
+		}
+	}
+	return b.String()
+}
+
+// placeholderFormatFor returns the registered format for relPath, nil for plain text.
+func placeholderFormatFor(relPath string) *placeholderFormat {
+	base := filepath.Base(relPath)
+	for i := range placeholderFormats {
+		for _, pattern := range placeholderFormats[i].Patterns {
+			if ok, _ := filepath.Match(pattern, base); ok {
+				return &placeholderFormats[i]
+			}
+		}
+	}
+	return nil
+}
+
+// GeneratePlaceholder returns the default placeholder for a given relative path (default
+// template, no owner or note), in the registered format for its file name if any.
+func GeneratePlaceholder(relPath string) []byte {
+	return formatPlaceholder(relPath, plainPlaceholder(relPath))
+}
+
+// placeholderBody renders the project's placeholder template for a file locked as entry.
+func (p *Project) placeholderBody(relPath string, entry *FileEntry) (string, error) {
+	cfg, err := p.Config()
+	if err != nil {
+		return "", err
+	}
+	body, err := renderPlaceholderBody(cfg.PlaceholderTemplate, PlaceholderVars{
+		Path:     relPath,
+		Owner:    cfg.Owner,
+		Note:     entry.Note,
+		LockedAt: entry.LockedAt,
+		Canary:   entry.Canary,
+	})
+	if err != nil {
+		return "", err
+	}
+	if entry.Canary != "" && !strings.Contains(body, entry.Canary) {
+		// A template without {{.Canary}} still carries the token
+		body += "Canary: " + entry.Canary + "\n"
+	}
+	return body, nil
+}
+
+// renderPlaceholder returns the ordinary placeholder for a file locked as entry, from
+// the project's template, in the file's format.
+func (p *Project) renderPlaceholder(relPath string, entry *FileEntry) ([]byte, error) {
+	body, err := p.placeholderBody(relPath, entry)
+	if err != nil {
+		return nil, err
+	}
+	return formatPlaceholder(relPath, body), nil
+}
+
+// placeholderCandidates returns every content accepted as relPath's placeholder: the
+// current one, and the plain text written for every file before formats existed.
+func placeholderCandidates(relPath string) [][]byte {
+	current := GeneratePlaceholder(relPath)
+	plain := []byte(plainPlaceholder(relPath))
+	if bytes.Equal(current, plain) {
+		return [][]byte{current}
+	}
+	return [][]byte{current, plain}
+}
+
+// IsPlaceholder checks if a file at the given path starts like an ignlnk placeholder of
+// any format.
+func IsPlaceholder(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, 64)
+	n, _ := io.ReadFull(f, buf)
+	head := string(buf[:n])
+	if strings.HasPrefix(head, placeholderPrefix) {
+		return true
+	}
+	for _, format := range placeholderFormats {
+		if strings.HasPrefix(head, format.Lead+placeholderPrefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPlaceholderAt reports whether the file at path is exactly a placeholder of a file
+// managed as entry: the project's template rendered for entry, the placeholder recorded
+// by hash at its last lock (redacted, decoy, or from a template since changed), or one
+// IsPlaceholderFor accepts.
+func (p *Project) isPlaceholderAt(path, relPath string, entry *FileEntry, size int64) bool {
+	if IsPlaceholderFor(path, relPath, size) {
+		return true
+	}
+	if entry == nil {
+		return false
+	}
+	// A regions placeholder starts like the original file, not with the marker
+	if !IsPlaceholder(path) {
+		return entry.Placeholder == PlaceholderRegions && entry.PlaceholderHash != "" && hashMatches(path, entry.PlaceholderHash)
+	}
+	if rendered, err := p.renderPlaceholder(relPath, entry); err == nil && size == int64(len(rendered)) {
+		if data, err := os.ReadFile(path); err == nil && bytes.Equal(data, rendered) {
+			return true
+		}
+	}
+	return entry.PlaceholderHash != "" && hashMatches(path, entry.PlaceholderHash)
+}
+
+// hashMatches reports whether the file at path hashes to hash.
+func hashMatches(path, hash string) bool {
+	got, err := HashFile(path)
+	return err == nil && got == hash
+}
+
+// IsPlaceholderFor checks if the file at path is exactly the default ignlnk placeholder for
+// relPath (see GeneratePlaceholder), or the plain-text one older versions wrote.
+// Requires size match (from Lstat) before comparing content, so a placeholder with
+// appended or edited content never passes.
+func IsPlaceholderFor(path, relPath string, size int64) bool {
+	var data []byte
+	for _, candidate := range placeholderCandidates(relPath) {
+		if size != int64(len(candidate)) {
+			continue
+		}
+		if data == nil {
+			var err error
+			if data, err = os.ReadFile(path); err != nil {
+				return false
+			}
+		}
+		if bytes.Equal(data, candidate) {
+			return true
+		}
+	}
+	return false
+}
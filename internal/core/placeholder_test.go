@@ -0,0 +1,149 @@
+package core
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlaceholderFormats(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		relPath string
+		format  string
+	}{
+		{"config/app.json", "json"},
+		{"deploy.yaml", "yaml"},
+		{"ci.yml", "yaml"},
+		{"Cargo.toml", "toml"},
+		{".env", "env"},
+		{".env.production", "env"},
+		{"secrets.env", "env"},
+		{"setup.ini", "ini"},
+		{"notes.txt", ""},
+	}
+	for _, tt := range tests {
+		content := GeneratePlaceholder(tt.relPath)
+		got := ""
+		if f := placeholderFormatFor(tt.relPath); f != nil {
+			got = f.Name
+		}
+		if got != tt.format {
+			t.Errorf("%s: expected format %q, got %q", tt.relPath, tt.format, got)
+		}
+		if !strings.Contains(string(content), "ignlnk unlock "+tt.relPath) {
+			t.Errorf("%s: placeholder lacks unlock hint: %q", tt.relPath, content)
+		}
+
+		switch tt.format {
+		case "json":
+			var v map[string]string
+			if err := json.Unmarshal(content, &v); err != nil || !strings.HasPrefix(v["_ignlnk"], placeholderPrefix) {
+				t.Errorf("%s: expected JSON object with _ignlnk message, got %v, %v", tt.relPath, v, err)
+			}
+		case "yaml", "toml", "env", "ini":
+			for _, line := range strings.Split(strings.TrimSuffix(string(content), "\n"), "\n") {
+				if !strings.HasPrefix(line, "#") && !strings.HasPrefix(line, ";") && line != "_ignlnk: protected" {
+					t.Errorf("%s: unexpected non-comment line %q", tt.relPath, line)
+				}
+			}
+		}
+
+		path := filepath.Join(dir, filepath.Base(tt.relPath))
+		if err := os.WriteFile(path, content, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if !IsPlaceholder(path) || !IsPlaceholderFor(path, tt.relPath, int64(len(content))) {
+			t.Errorf("%s: own placeholder not recognized", tt.relPath)
+		}
+		if IsPlaceholderFor(path, "other/"+tt.relPath, int64(len(content))) {
+			t.Errorf("%s: placeholder accepted for another path", tt.relPath)
+		}
+
+		// Same size, edited content must not pass
+		spoof := []byte(strings.Replace(string(content), "protected", "PROTECTED", 1))
+		if err := os.WriteFile(path, spoof, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if IsPlaceholderFor(path, tt.relPath, int64(len(spoof))) {
+			t.Errorf("%s: edited placeholder accepted", tt.relPath)
+		}
+	}
+}
+
+func TestPlainPlaceholderStillRecognized(t *testing.T) {
+	// Files locked before placeholder formats existed keep the plain text
+	relPath := "config.json"
+	path := filepath.Join(t.TempDir(), relPath)
+	plain := []byte(plainPlaceholder(relPath))
+	if err := os.WriteFile(path, plain, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if !IsPlaceholderFor(path, relPath, int64(len(plain))) {
+		t.Fatal("expected plain placeholder recognized for a formatted file")
+	}
+}
+
+func TestPlaceholderTemplateAndNote(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+	p.config = &Config{Owner: "ops@example.com"}
+
+	relPath := "app.json"
+	absPath := p.AbsPath(relPath)
+	if err := os.WriteFile(absPath, []byte(`{"token": "abc"}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFileAs(p, v, m, relPath, LockOptions{Note: "rotated weekly"}); err != nil {
+		t.Fatalf("LockFileAs failed: %v", err)
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]string
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("placeholder is not valid JSON: %v\n%s", err, data)
+	}
+	for _, want := range []string{"Owner: ops@example.com.", "Note: rotated weekly", "ignlnk unlock app.json"} {
+		if !strings.Contains(doc["_ignlnk"], want) {
+			t.Errorf("placeholder lacks %q: %s", want, data)
+		}
+	}
+	entry := m.Files[relPath]
+	if entry.Note != "rotated weekly" || entry.PlaceholderHash == "" {
+		t.Fatalf("expected note and placeholder hash recorded, got %+v", entry)
+	}
+
+	// Changing the template leaves the placeholder recognized until the next lock
+	p.config = &Config{PlaceholderTemplate: "Locked since {{.LockedAt}}.{{if .Note}} {{.Note}}{{end}}"}
+	if status := FileStatus(p, v, entry, relPath, nil); status != "locked" {
+		t.Fatalf("expected locked after template change, got %q", status)
+	}
+
+	// A new note rewrites the placeholder with the current template
+	if err := LockFileAs(p, v, m, relPath, LockOptions{Note: "ask ops"}); err != nil {
+		t.Fatalf("LockFileAs failed: %v", err)
+	}
+	data, err = os.ReadFile(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"_ignlnk": "[ignlnk:protected] Locked since ` + entry.LockedAt + `. ask ops"}` + "\n"
+	if string(data) != want {
+		t.Fatalf("expected %q, got %q", want, data)
+	}
+	if status := FileStatus(p, v, m.Files[relPath], relPath, nil); status != "locked" {
+		t.Fatalf("expected locked, got %q", status)
+	}
+
+	// An edited placeholder is not accepted
+	if err := os.WriteFile(absPath, []byte(strings.Replace(want, "ask", "ASK", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if status := FileStatus(p, v, m.Files[relPath], relPath, nil); status == "locked" {
+		t.Fatal("expected edited placeholder not to be locked")
+	}
+}
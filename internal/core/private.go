@@ -0,0 +1,79 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// PrivateView prepares a locked file for 'exec --private' and returns the plaintext file
+// to bind-mount over its placeholder: the vault file itself, or an encrypted vault's
+// decrypted working copy. The project tree is not touched and the file stays locked.
+func PrivateView(project *Project, vault *Vault, manifest *Manifest, relPath string) (string, error) {
+	entry := manifest.entry(relPath)
+	if entry == nil {
+		return "", fmt.Errorf("file not managed: %s", relPath)
+	}
+	if entry.State != "locked" {
+		return "", fmt.Errorf("%s is %s — only locked files get a private view", relPath, entry.State)
+	}
+	if entry.Dir {
+		return "", fmt.Errorf("%s: a private view is %w", relPath, ErrDirUnsupported)
+	}
+	if entry.Hidden {
+		return "", fmt.Errorf("%s is hidden — its real path has no placeholder to mount a private view over", relPath)
+	}
+
+	// The bind mount needs the placeholder as its mount point
+	absPath := project.AbsPath(relPath)
+	info, err := os.Lstat(absPath)
+	if err != nil || !info.Mode().IsRegular() || !project.isPlaceholderAt(absPath, relPath, entry, info.Size()) {
+		return "", fmt.Errorf("%s is not its placeholder — run 'ignlnk verify'", relPath)
+	}
+
+	if err := vault.requirePlainDir(); err != nil {
+		return "", err
+	}
+	vaultPath := vault.FilePath(relPath)
+	workPath := vault.WorkPath(relPath)
+	if workPath != vaultPath {
+		if err := vault.restoreFile(vaultPath, workPath); err != nil {
+			return "", fmt.Errorf("decrypting vault file: %w", err)
+		}
+	}
+	hash, err := HashFile(workPath)
+	if err != nil {
+		removeWorkCopy(vault, relPath)
+		return "", fmt.Errorf("verifying vault file: %w", err)
+	}
+	if hash != entry.Hash {
+		fmt.Fprintf(os.Stderr, "warning: vault file hash mismatch for %s — run 'ignlnk repair %s'\n", filepath.FromSlash(relPath), filepath.FromSlash(relPath))
+	}
+	return workPath, nil
+}
+
+// SyncPrivateView ends a private view: accepts edits made through it as the file's new
+// vault content, as re-lock does, and removes a decrypted working copy. A file that was
+// unlocked or forgotten in the meantime is left alone. Returns false if unchanged.
+func SyncPrivateView(project *Project, vault *Vault, manifest *Manifest, relPath string) (bool, error) {
+	entry := manifest.entry(relPath)
+	if entry == nil || entry.State != "locked" {
+		return false, nil
+	}
+
+	if err := project.journal("commit", relPath, "begin", entry.Hash); err != nil {
+		return false, err
+	}
+	updated := *entry
+	changed, err := commitVaultCopy(vault, &updated, relPath, vault.WorkPath(relPath))
+	if err != nil {
+		return false, err
+	}
+	removeWorkCopy(vault, relPath)
+	if changed {
+		manifest.setEntry(relPath, &updated)
+		project.journalStep("commit", relPath, "committed", updated.Hash)
+		warnCapture(project, vault, relPath, "exec")
+	}
+	return changed, nil
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+func TestPrivateViewSyncsEdits(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	if err := os.WriteFile(absPath, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := PrivateView(p, v, m, relPath); err == nil {
+		t.Fatal("expected PrivateView to fail for unmanaged file")
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+
+	src, err := PrivateView(p, v, m, relPath)
+	if err != nil {
+		t.Fatalf("PrivateView failed: %v", err)
+	}
+	if got, err := os.ReadFile(src); err != nil || string(got) != "original" {
+		t.Fatalf("expected view of original content, got %q, %v", got, err)
+	}
+	if got := FileStatus(p, v, m.Files[relPath], relPath, nil); got != "locked" {
+		t.Fatalf("expected file to stay locked, got %s", got)
+	}
+
+	// Edit through the view, as the command would through its bind mount
+	if err := os.WriteFile(src, []byte("edited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed, err := SyncPrivateView(p, v, m, relPath)
+	if err != nil || !changed {
+		t.Fatalf("expected edits synced, got changed=%v err=%v", changed, err)
+	}
+	editedHash, err := HashFile(v.FilePath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Files[relPath].State != "locked" || m.Files[relPath].Hash != editedHash {
+		t.Fatalf("expected locked entry with hash %s, got %+v", editedHash, m.Files[relPath])
+	}
+	if got, err := os.ReadFile(v.BackupPath(relPath)); err != nil || string(got) != "edited" {
+		t.Fatalf("expected backup rotated to edits, got %q, %v", got, err)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofrs/flock"
@@ -17,20 +19,136 @@ import (
 type Manifest struct {
 	Version int                   `json:"version"`
 	Files   map[string]*FileEntry `json:"files"`
+
+	mu sync.Mutex // Guards Files while file ops run on parallel workers
+}
+
+// entry returns the entry for relPath, or nil. Entries are replaced via setEntry rather
+// than mutated, so the returned value is safe to read while other workers run.
+func (m *Manifest) entry(relPath string) *FileEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.Files[relPath]
+}
+
+// setEntry stores e as the entry for relPath.
+func (m *Manifest) setEntry(relPath string, e *FileEntry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Files[relPath] = e
 }
 
 // FileEntry represents a single managed file
 type FileEntry struct {
-	State    string `json:"state"`    // "locked" or "unlocked"
-	LockedAt string `json:"lockedAt"` // ISO 8601 timestamp
-	Hash     string `json:"hash"`     // "sha256:<hex>"
+	State      string `json:"state"`                // "locked", "unlocked" (symlink) or "unlocked-copy" (real copy)
+	LockedAt   string `json:"lockedAt"`             // ISO 8601 timestamp
+	Hash       string `json:"hash"`                 // "sha256:<hex>"
+	UnlockMode string `json:"unlockMode,omitempty"` // Per-file default for unlock: "symlink" or "copy"
+	ReadOnly   bool   `json:"readOnly,omitempty"`   // Unlocked read-only: write bits stripped, edits never committed
+	Perm       uint32 `json:"perm,omitempty"`       // Permissions to restore on re-lock after a read-only unlock
+	ExpiresAt  string `json:"expiresAt,omitempty"`  // ISO 8601; a time-limited unlock is re-locked after this
+	// Placeholder mode kept for re-locks: "redacted", "decoy", "regions", or "" for the
+	// ordinary placeholder (the project template, written in the file's config format)
+	Placeholder string `json:"placeholder,omitempty"`
+	Note        string `json:"note,omitempty"`   // Shown in the placeholder ('lock --note')
+	Canary      string `json:"canary,omitempty"` // Token embedded in the placeholder ('lock --canary')
+	// Hash of the placeholder written at the last lock if it is not the default one:
+	// redacted, decoy, or from a template, which may change before the next lock
+	PlaceholderHash string `json:"placeholderHash,omitempty"`
+	// A directory managed as one entry: Hash is its TreeHash, the placeholder a directory
+	// holding only DirPlaceholderName, and it unlocks as a directory symlink
+	Dir bool `json:"dir,omitempty"`
+	// Managed under a generic placeholder name (see HiddenPrefix); the real path is
+	// only in the vault's names file
+	Hidden bool `json:"hidden,omitempty"`
+}
+
+// Unlock modes: a symlink into the vault, or a real copy synced back on re-lock.
+const (
+	UnlockModeSymlink = "symlink"
+	UnlockModeCopy    = "copy"
+)
+
+// Placeholder modes: placeholders derived from the file's content, recorded by hash.
+const (
+	PlaceholderRedacted = "redacted" // Values replaced by <redacted>
+	PlaceholderDecoy    = "decoy"    // Values replaced by plausible fakes
+	PlaceholderRegions  = "regions"  // Only the ignlnk:begin/end regions replaced
+)
+
+// Unlocked reports whether the file is unlocked in either mode.
+func (e *FileEntry) Unlocked() bool {
+	return e.State == "unlocked" || e.State == "unlocked-copy"
+}
+
+// Expiry returns when a time-limited unlock expires; false if the file is not unlocked
+// with a (valid) expiry.
+func (e *FileEntry) Expiry() (time.Time, bool) {
+	if !e.Unlocked() || e.ExpiresAt == "" {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339, e.ExpiresAt)
+	return t, err == nil
+}
+
+// ExpiredFiles returns the sorted paths of time-limited unlocks that expired by now.
+func (m *Manifest) ExpiredFiles(now time.Time) []string {
+	var expired []string
+	for relPath, entry := range m.Files {
+		if t, ok := entry.Expiry(); ok && !now.Before(t) {
+			expired = append(expired, relPath)
+		}
+	}
+	sort.Strings(expired)
+	return expired
+}
+
+// NextExpiry returns the earliest pending unlock expiry; false if there is none.
+func (m *Manifest) NextExpiry() (time.Time, bool) {
+	var next time.Time
+	found := false
+	for _, entry := range m.Files {
+		if t, ok := entry.Expiry(); ok && (!found || t.Before(next)) {
+			next, found = t, true
+		}
+	}
+	return next, found
 }
 
+// Config represents .ignlnk/config.json. The file is optional; defaults apply when absent.
+type Config struct {
+	HistoryKeep *int   `json:"historyKeep,omitempty"` // Revisions kept per file; 0 disables history
+	AutoRepair  bool   `json:"autoRepair,omitempty"`  // Heal a corrupted vault copy from its backup on unlock
+	UnlockMode  string `json:"unlockMode,omitempty"`  // Project default for unlock: "symlink" (default) or "copy"
+	// Placeholder body as a Go text/template over PlaceholderVars (default:
+	// DefaultPlaceholderTemplate)
+	PlaceholderTemplate string `json:"placeholderTemplate,omitempty"`
+	Owner               string `json:"owner,omitempty"`  // Who to ask about locked files, for the template
+	Canary              bool   `json:"canary,omitempty"` // Embed a canary token in newly locked files' placeholders
+}
+
+const defaultHistoryKeep = 20
+
 // Project represents a detected ignlnk project
 type Project struct {
 	Root         string // Absolute path to project root
 	IgnlnkDir    string // Absolute path to .ignlnk/
 	ManifestPath string // Absolute path to .ignlnk/manifest.json
+
+	config    *Config    // Loaded lazily by Config()
+	configMu  sync.Mutex // Guards config
+	journalMu sync.Mutex // Serializes journal appends
+}
+
+// RevisionsToKeep returns the number of vault revisions to retain per file.
+func (c *Config) RevisionsToKeep() int {
+	if c.HistoryKeep == nil {
+		return defaultHistoryKeep
+	}
+	if *c.HistoryKeep < 0 {
+		return 0
+	}
+	return *c.HistoryKeep
 }
 
 // FindProject walks up from startDir looking for a .ignlnk/ directory.
@@ -98,9 +216,21 @@ func (p *Project) LoadManifest() (*Manifest, error) {
 	return &m, nil
 }
 
-// SaveManifest writes manifest.json atomically.
+// SaveManifest writes manifest.json atomically, then clears the operation journal:
+// the saved manifest now reflects every journaled operation.
 func (p *Project) SaveManifest(m *Manifest) error {
+	if err := p.WriteManifest(m); err != nil {
+		return err
+	}
+	return p.clearJournal()
+}
+
+// WriteManifest writes manifest.json atomically but keeps the journal, for saves that
+// may race an in-flight operation (signal handler). The next command recovers from it.
+func (p *Project) WriteManifest(m *Manifest) error {
+	m.mu.Lock()
 	data, err := json.MarshalIndent(m, "", "  ")
+	m.mu.Unlock()
 	if err != nil {
 		return fmt.Errorf("marshaling manifest: %w", err)
 	}
@@ -112,6 +242,29 @@ func (p *Project) SaveManifest(m *Manifest) error {
 	return nil
 }
 
+// Config returns the project configuration from .ignlnk/config.json, loading it once.
+func (p *Project) Config() (*Config, error) {
+	p.configMu.Lock()
+	defer p.configMu.Unlock()
+	if p.config != nil {
+		return p.config, nil
+	}
+	data, err := os.ReadFile(filepath.Join(p.IgnlnkDir, "config.json"))
+	if os.IsNotExist(err) {
+		p.config = &Config{}
+		return p.config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading config: %w", err)
+	}
+	var c Config
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("parsing config: %w", err)
+	}
+	p.config = &c
+	return p.config, nil
+}
+
 // LockManifest acquires an exclusive file lock on .ignlnk/manifest.lock.
 // Returns an unlock function for defer. Times out after 30 seconds.
 func (p *Project) LockManifest() (unlock func(), err error) {
@@ -0,0 +1,542 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	redactedValue   = "<redacted>"
+	redactSizeLimit = 1024 * 1024 // Larger files get the ordinary placeholder
+	redactedNotice  = "Values are " + redactedValue + "; only the keys are real."
+
+	redactableFormats = ".env, JSON, YAML, INI"
+)
+
+// scrubFunc returns what replaces value, the original (unquoted) value at key path key,
+// in a redacted or decoy placeholder.
+type scrubFunc func(key, value string) string
+
+// RedactedPlaceholder returns a placeholder for relPath that lists the keys of its
+// plaintext content with every value replaced by <redacted>, for .env, JSON, YAML and
+// INI files. The result still parses in the file's format and starts like any other
+// placeholder of that format. Fails for other formats or content it cannot parse.
+func RedactedPlaceholder(relPath string, content []byte) ([]byte, error) {
+	return redactedPlaceholder(relPath, plainPlaceholder(relPath), content)
+}
+
+// redactedPlaceholder is RedactedPlaceholder with the given placeholder body (see
+// Project.placeholderBody) as the notice.
+func redactedPlaceholder(relPath, body string, content []byte) ([]byte, error) {
+	return scrubbedPlaceholder(relPath, scrubbedNotice(body, redactedNotice), content, func(key, value string) string {
+		return redactedValue
+	})
+}
+
+// scrubbedPlaceholder renders relPath's content through its format's Scrub after notice.
+func scrubbedPlaceholder(relPath, notice string, content []byte, scrub scrubFunc) ([]byte, error) {
+	if len(content) > redactSizeLimit {
+		return nil, fmt.Errorf("too large to redact (over %d KB)", redactSizeLimit/1024)
+	}
+	if !Redactable(relPath) {
+		return nil, fmt.Errorf("no redacted placeholder for this file type (supported: %s)", redactableFormats)
+	}
+	return placeholderFormatFor(relPath).Scrub(notice, content, scrub)
+}
+
+// Redactable reports whether relPath's file type supports redacted and decoy placeholders.
+func Redactable(relPath string) bool {
+	format := placeholderFormatFor(relPath)
+	return format != nil && format.Scrub != nil
+}
+
+// scrubbedNotice is a placeholder body with sentence, saying what the values are,
+// appended to its first line.
+func scrubbedNotice(body, sentence string) string {
+	first, rest, _ := strings.Cut(body, "\n")
+	return strings.TrimRight(first, " ") + " " + sentence + "\n" + rest
+}
+
+// scrubLines writes header, then every line of content through scrubLine, which returns
+// the scrubbed line and whether to keep it, or an error for a line it cannot parse
+// (the file then gets the ordinary placeholder rather than guessed keys). Comments and
+// blank lines are dropped, as comments may hold values too.
+func scrubLines(header string, content []byte, scrubLine func(line string) (string, bool, error)) ([]byte, error) {
+	var b strings.Builder
+	b.WriteString(header)
+	for n, line := range strings.Split(strings.ReplaceAll(string(content), "\r\n", "\n"), "\n") {
+		out, ok, err := scrubLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n+1, err)
+		}
+		if ok {
+			b.WriteString(out + "\n")
+		}
+	}
+	return []byte(b.String()), nil
+}
+
+func scrubEnv(notice string, content []byte, scrub scrubFunc) ([]byte, error) {
+	var open *envValueLines
+	out, err := scrubLines(commentLines(notice, "#"), content, func(line string) (string, bool, error) {
+		if open != nil {
+			out, done, err := open.add(line, scrub)
+			if done {
+				open = nil
+			}
+			return out, done, err
+		}
+		out, keep, next, err := scrubEnvLine(line, scrub)
+		open = next
+		return out, keep, err
+	})
+	if err == nil && open != nil {
+		return nil, fmt.Errorf("unterminated quoted value for %s", open.key)
+	}
+	return out, err
+}
+
+// envValueLines collects a quoted .env value spanning several lines until its closing
+// quote; none of its lines is kept.
+type envValueLines struct {
+	prefix, key string
+	quote       byte
+	value       string
+}
+
+// add takes the next line of the value, returning the assignment once it is complete.
+func (v *envValueLines) add(line string, scrub scrubFunc) (string, bool, error) {
+	i := closingQuote(line, v.quote)
+	if i < 0 {
+		v.value += "\n" + line
+		return "", false, nil
+	}
+	if rest := strings.TrimSpace(line[i+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+		return "", false, fmt.Errorf("unexpected text after the value of %s", v.key)
+	}
+	value := v.value + "\n" + line[:i]
+	return v.prefix + v.key + "=" + envValue(scrub(v.key, value)), true, nil
+}
+
+// scrubEnvLine keeps KEY=<scrubbed> (and a leading "export") of a .env assignment. A
+// quoted value left open continues on the following lines, returned as next.
+func scrubEnvLine(line string, scrub scrubFunc) (out string, keep bool, next *envValueLines, err error) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+		return "", false, nil, nil
+	}
+	key, value, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return "", false, nil, fmt.Errorf("not an assignment")
+	}
+	prefix := ""
+	if rest, found := strings.CutPrefix(key, "export "); found {
+		prefix, key = "export ", strings.TrimSpace(rest)
+	}
+	key = strings.TrimSpace(key)
+	if !isEnvKey(key) {
+		return "", false, nil, fmt.Errorf("invalid key %q", key)
+	}
+	value = strings.TrimSpace(value)
+	if value != "" && (value[0] == '"' || value[0] == '\'') && closingQuote(value[1:], value[0]) < 0 {
+		return "", false, &envValueLines{prefix: prefix, key: key, quote: value[0], value: value[1:]}, nil
+	}
+	value, _ = unquoteValue(value)
+	return prefix + key + "=" + envValue(scrub(key, value)), true, nil, nil
+}
+
+// closingQuote returns the index in s of the first quote character not escaped by a
+// backslash (escapes count only in double quotes), or -1.
+func closingQuote(s string, quote byte) int {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quote == '"':
+			i++
+		case s[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func isEnvKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i, c := range key {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' || c == '.' || c == '-':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// envValue quotes a .env value if it would not survive unquoted.
+func envValue(v string) string {
+	if strings.ContainsAny(v, " \t\n#\"'$`\\") {
+		return jsonString(v)
+	}
+	return v
+}
+
+// unquoteValue trims a scalar value and strips its quotes, or an unquoted value's
+// trailing " # comment". Reports whether the value was quoted.
+func unquoteValue(value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		if value[0] == '"' {
+			if s, err := strconv.Unquote(value); err == nil {
+				return s, true
+			}
+		}
+		return value[1 : len(value)-1], true
+	}
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, false
+}
+
+// scrubINI keeps section headers and keys. A line indented deeper than the key before
+// it continues that key's value and is dropped.
+func scrubINI(notice string, content []byte, scrub scrubFunc) ([]byte, error) {
+	section := ""
+	keyIndent := -1 // Indentation of the last key line, -1 before any key in the section
+	return scrubLines(commentLines(notice, ";"), content, func(line string) (string, bool, error) {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		switch {
+		case trimmed == "":
+			return "", false, nil
+		case keyIndent >= 0 && indent > keyIndent:
+			return "", false, nil
+		case strings.HasPrefix(trimmed, ";"), strings.HasPrefix(trimmed, "#"):
+			return "", false, nil
+		case strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]"):
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			keyIndent = -1
+			return trimmed, true, nil
+		}
+		i := strings.IndexAny(trimmed, "=:")
+		if i < 0 {
+			return "", false, fmt.Errorf("not a key or section")
+		}
+		key := strings.TrimSpace(trimmed[:i])
+		if !isEnvKey(key) {
+			return "", false, fmt.Errorf("invalid key %q", key)
+		}
+		keyIndent = indent
+		value, _ := unquoteValue(trimmed[i+1:])
+		return key + " = " + scrub(joinKey(section, key), value), true, nil
+	})
+}
+
+func joinKey(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+// scrubYAML handles YAML block style line by line: mapping keys and list items keep
+// their indentation and keys, scalar values are scrubbed, and block scalars (| or >)
+// become a single scrubbed value, dropping their more-indented lines.
+func scrubYAML(notice string, content []byte, scrub scrubFunc) ([]byte, error) {
+	type level struct {
+		indent int
+		key    string
+	}
+	var parents []level
+	blockIndent := -1 // Indentation of the key owning a block scalar being skipped
+	return scrubLines(commentLines(notice, "#"), content, func(line string) (string, bool, error) {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		if blockIndent >= 0 {
+			if trimmed == "" || indent > blockIndent {
+				return "", false, nil
+			}
+			blockIndent = -1
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			return "", false, nil
+		}
+		if trimmed == "---" || trimmed == "..." {
+			parents = nil
+			return trimmed, true, nil
+		}
+
+		pad := line[:indent]
+		item := ""
+		if rest, ok := strings.CutPrefix(trimmed, "- "); ok || trimmed == "-" {
+			item, trimmed = "- ", strings.TrimSpace(rest)
+		}
+		for len(parents) > 0 && parents[len(parents)-1].indent >= indent {
+			parents = parents[:len(parents)-1]
+		}
+		parent := ""
+		for _, p := range parents {
+			parent = joinKey(parent, p.key)
+		}
+		if item != "" && trimmed == "" {
+			return pad + "-", true, nil
+		}
+
+		key, value, isPair := cutYAMLKey(trimmed)
+		if !isPair {
+			value, quoted := unquoteValue(trimmed)
+			return pad + item + yamlValue(scrub(joinKey(parent, "-"), value), quoted), true, nil
+		}
+		value = strings.TrimSpace(value)
+		if value == "" || strings.HasPrefix(value, "#") {
+			keyIndent := indent
+			if item != "" {
+				keyIndent += 2 // Keys of a list item's mapping sit after the "- "
+			}
+			parents = append(parents, level{keyIndent, key})
+			return pad + item + key + ":", true, nil
+		}
+		quoted := false
+		if strings.HasPrefix(value, "|") || strings.HasPrefix(value, ">") {
+			blockIndent, value = indent, ""
+		} else {
+			value, quoted = unquoteValue(value)
+		}
+		return pad + item + key + ": " + yamlValue(scrub(joinKey(parent, key), value), quoted), true, nil
+	})
+}
+
+// yamlValue renders a scrubbed YAML scalar, quoting it unless it is safe as a plain
+// scalar; numbers and booleans stay plain only if the original was.
+func yamlValue(v string, wasQuoted bool) string {
+	if v == "" {
+		return `""`
+	}
+	if isYAMLLiteral(v) {
+		if wasQuoted {
+			return jsonString(v)
+		}
+		return v
+	}
+	if strings.ContainsAny(v[:1], "-?:,[]{}#&*!|>'\"%@` ") || strings.Contains(v, ": ") ||
+		strings.Contains(v, " #") || strings.HasSuffix(v, " ") || strings.HasSuffix(v, ":") {
+		return jsonString(v)
+	}
+	return v
+}
+
+// isYAMLLiteral reports whether v would parse as a non-string plain scalar.
+func isYAMLLiteral(v string) bool {
+	switch strings.ToLower(v) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	_, err := strconv.ParseFloat(v, 64)
+	return err == nil
+}
+
+// cutYAMLKey splits "key: value" at the first ": " (or a trailing ":") outside quotes.
+func cutYAMLKey(s string) (key, value string, ok bool) {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			if i == 0 {
+				quote = c
+			}
+		case c == ':' && (i == len(s)-1 || s[i+1] == ' ' || s[i+1] == '\t'):
+			if i == 0 {
+				return "", "", false
+			}
+			return s[:i], s[i+1:], true
+		case c == '#' && i > 0 && s[i-1] == ' ':
+			return "", "", false
+		}
+	}
+	return "", "", false
+}
+
+// scrubJSON rewrites a JSON object keeping its key structure (in order) with every
+// scalar scrubbed, and the placeholder notice as the first key.
+func scrubJSON(notice string, content []byte, scrub scrubFunc) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+	if tok != json.Delim('{') {
+		return nil, fmt.Errorf("parsing JSON: top level is not an object")
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "{\"_ignlnk\": %s", jsonString(singleLine(notice)))
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, fmt.Errorf("parsing JSON: %w", err)
+		}
+		key := tok.(string)
+		fmt.Fprintf(&b, ",\n  %s: ", jsonString(key))
+		if err := scrubJSONValue(dec, &b, "  ", key, scrub); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := dec.Token(); err != nil { // Closing brace
+		return nil, fmt.Errorf("parsing JSON: %w", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("parsing JSON: trailing data after top-level object")
+	}
+	b.WriteString("\n}\n")
+	return b.Bytes(), nil
+}
+
+// scrubJSONValue copies the next value at key path key, scrubbing scalars and recursing
+// into containers. A scrubbed number, boolean or null stays unquoted if still valid.
+func scrubJSONValue(dec *json.Decoder, b *bytes.Buffer, indent, key string, scrub scrubFunc) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return fmt.Errorf("parsing JSON: %w", err)
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		if s, isString := tok.(string); isString {
+			b.WriteString(jsonString(scrub(key, s)))
+			return nil
+		}
+		literal := "null"
+		if tok != nil {
+			literal = fmt.Sprint(tok)
+		}
+		if v := scrub(key, literal); v != "" && v[0] != '"' && json.Valid([]byte(v)) {
+			b.WriteString(v)
+		} else {
+			b.WriteString(jsonString(v))
+		}
+		return nil
+	}
+	inner := indent + "  "
+	switch delim {
+	case '{':
+		b.WriteString("{")
+		first := true
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return fmt.Errorf("parsing JSON: %w", err)
+			}
+			if !first {
+				b.WriteString(",")
+			}
+			first = false
+			fmt.Fprintf(b, "\n%s%s: ", inner, jsonString(tok.(string)))
+			if err := scrubJSONValue(dec, b, inner, joinKey(key, tok.(string)), scrub); err != nil {
+				return err
+			}
+		}
+		if !first {
+			b.WriteString("\n" + indent)
+		}
+		b.WriteString("}")
+	case '[':
+		b.WriteString("[")
+		n := 0
+		for ; dec.More(); n++ {
+			if n > 0 {
+				b.WriteString(",")
+			}
+			b.WriteString("\n" + inner)
+			if err := scrubJSONValue(dec, b, inner, joinKey(key, strconv.Itoa(n)), scrub); err != nil {
+				return err
+			}
+		}
+		if n > 0 {
+			b.WriteString("\n" + indent)
+		}
+		b.WriteString("]")
+	}
+	if _, err := dec.Token(); err != nil { // Closing delimiter
+		return fmt.Errorf("parsing JSON: %w", err)
+	}
+	return nil
+}
+
+// jsonString encodes s as a JSON string without escaping <, > and &.
+func jsonString(s string) string {
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// lockPlaceholder returns the placeholder to write when locking relPath as entry (its
+// Placeholder mode, Note and LockedAt), rendered from the project's template, plus its
+// hash if it is not the default one, recorded as FileEntry.PlaceholderHash. A redacted,
+// decoy or regions placeholder comes from the vault copy, so call it once that holds the
+// content being locked. A file that cannot be parsed gets the ordinary placeholder,
+// with a warning.
+func lockPlaceholder(project *Project, vault *Vault, relPath string, entry *FileEntry) ([]byte, string, error) {
+	body, err := project.placeholderBody(relPath, entry)
+	if err != nil {
+		return nil, "", err
+	}
+	placeholder := formatPlaceholder(relPath, body)
+	if entry.Placeholder != "" {
+		scrubbed, err := scrubStored(vault, relPath, entry, body)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %s: no %s placeholder, using the plain one: %v\n", filepath.FromSlash(relPath), entry.Placeholder, err)
+		} else {
+			placeholder = scrubbed
+		}
+	}
+	if bytes.Equal(placeholder, GeneratePlaceholder(relPath)) {
+		return placeholder, "", nil
+	}
+	return placeholder, hashBytes(placeholder), nil
+}
+
+func scrubStored(vault *Vault, relPath string, entry *FileEntry, body string) ([]byte, error) {
+	vaultPath := vault.FilePath(relPath)
+	if info, err := os.Stat(vaultPath); err != nil {
+		return nil, err
+	} else if info.Size() > redactSizeLimit+1024 { // Allow for encryption overhead
+		return nil, fmt.Errorf("too large to redact (over %d KB)", redactSizeLimit/1024)
+	}
+	content, err := vault.readStored(vaultPath)
+	if err != nil {
+		return nil, fmt.Errorf("reading vault copy: %w", err)
+	}
+	switch entry.Placeholder {
+	case PlaceholderDecoy:
+		return decoyPlaceholder(vault.UID, relPath, body, content)
+	case PlaceholderRegions:
+		return RegionsPlaceholder(relPath, content, entry.Canary)
+	}
+	return redactedPlaceholder(relPath, body, content)
+}
+
+// hashBytes returns the SHA-256 of data in HashFile's "sha256:<hex>" form.
+func hashBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,252 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Region markers, each alone on a line in the file's own comment syntax (e.g.
+// "# ignlnk:begin", "// ignlnk:end", "<!-- ignlnk:begin -->").
+const (
+	regionBegin = "ignlnk:begin"
+	regionEnd   = "ignlnk:end"
+)
+
+// region is a marked block: the line indexes of its begin and end markers.
+type region struct {
+	begin, end int
+}
+
+// splitLines splits content after each newline, keeping line endings (and CRLF) intact.
+func splitLines(content []byte) []string {
+	lines := strings.SplitAfter(string(content), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// markerLine reports whether line holds marker and nothing but comment syntax around it,
+// so prose that merely mentions a marker is not one.
+func markerLine(line, marker string) bool {
+	i := strings.Index(line, marker)
+	if i < 0 {
+		return false
+	}
+	outside := line[:i] + line[i+len(marker):]
+	return strings.IndexFunc(outside, func(r rune) bool {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}) < 0
+}
+
+// parseRegions finds the marked regions in lines. Markers must pair up and not nest.
+func parseRegions(lines []string) ([]region, error) {
+	var regions []region
+	open := -1
+	for i, line := range lines {
+		switch {
+		case markerLine(line, regionBegin):
+			if open >= 0 {
+				return nil, fmt.Errorf("line %d: %s inside the region opened on line %d", i+1, regionBegin, open+1)
+			}
+			open = i
+		case markerLine(line, regionEnd):
+			if open < 0 {
+				return nil, fmt.Errorf("line %d: %s without %s", i+1, regionEnd, regionBegin)
+			}
+			regions = append(regions, region{begin: open, end: i})
+			open = -1
+		}
+	}
+	if open >= 0 {
+		return nil, fmt.Errorf("line %d: %s without %s", open+1, regionBegin, regionEnd)
+	}
+	return regions, nil
+}
+
+// HasRegions reports whether content has at least one region marker, so a first lock
+// only moves the marked regions into the vault (see RegionsPlaceholder).
+func HasRegions(content []byte) bool {
+	for _, line := range splitLines(content) {
+		if markerLine(line, regionBegin) {
+			return true
+		}
+	}
+	return false
+}
+
+// fileHasRegions is HasRegions for the file at path, false if it cannot be read or is
+// too large for a regions placeholder.
+func fileHasRegions(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.Size() > redactSizeLimit {
+		return false
+	}
+	content, err := os.ReadFile(path)
+	return err == nil && HasRegions(content)
+}
+
+// regionLine is the one-line placeholder standing in for a region, in the comment syntax
+// of its begin marker line.
+func regionLine(beginLine, relPath, canary string) string {
+	i := strings.Index(beginLine, regionBegin)
+	before := beginLine[:i]
+	after := strings.TrimRight(beginLine[i+len(regionBegin):], "\r\n")
+	eol := beginLine[len(strings.TrimRight(beginLine, "\r\n")):]
+	text := placeholderPrefix + " This region is protected by ignlnk. To view it, ask the user to run: ignlnk unlock " + relPath
+	if canary != "" {
+		text += " -- Canary: " + canary
+	}
+	return before + text + after + eol
+}
+
+// RegionsPlaceholder returns content with the lines of every marked region replaced by
+// a one-line placeholder comment; the markers and everything outside them stay. Fails
+// if content has no regions or its markers do not pair up.
+func RegionsPlaceholder(relPath string, content []byte, canary string) ([]byte, error) {
+	lines := splitLines(content)
+	regions, err := parseRegions(lines)
+	if err != nil {
+		return nil, err
+	}
+	if len(regions) == 0 {
+		return nil, fmt.Errorf("no %s/%s markers", regionBegin, regionEnd)
+	}
+	var b strings.Builder
+	next := 0
+	for _, r := range regions {
+		b.WriteString(strings.Join(lines[next:r.begin+1], ""))
+		b.WriteString(regionLine(lines[r.begin], relPath, canary))
+		next = r.end
+	}
+	b.WriteString(strings.Join(lines[next:], ""))
+	return []byte(b.String()), nil
+}
+
+// lockedRegions parses a region-locked file as edited while locked, checking that every
+// region still holds exactly its placeholder line.
+func lockedRegions(relPath string, edited []byte, canary string) ([]string, []region, error) {
+	lines := splitLines(edited)
+	regions, err := parseRegions(lines)
+	if err != nil {
+		return nil, nil, fmt.Errorf("region markers changed while locked: %w", err)
+	}
+	for i, r := range regions {
+		if r.end != r.begin+2 || lines[r.begin+1] != regionLine(lines[r.begin], relPath, canary) {
+			return nil, nil, fmt.Errorf("region %d (line %d) was changed while locked", i+1, r.begin+1)
+		}
+	}
+	return lines, regions, nil
+}
+
+// mergeRegions reassembles a region-locked file whose readable portion was edited while
+// locked: edited with each region placeholder replaced by the region from stored, the
+// full content in the vault. Fails if the edits touched a region placeholder or the
+// markers, which would put secrets in the wrong place.
+func mergeRegions(relPath string, edited, stored []byte, canary string) ([]byte, error) {
+	editedLines, editedRegions, err := lockedRegions(relPath, edited, canary)
+	if err != nil {
+		return nil, err
+	}
+	storedLines := splitLines(stored)
+	storedRegions, err := parseRegions(storedLines)
+	if err != nil {
+		return nil, fmt.Errorf("vault copy: %w", err)
+	}
+	if len(editedRegions) != len(storedRegions) {
+		return nil, fmt.Errorf("region markers changed while locked: %d regions, the vault copy has %d", len(editedRegions), len(storedRegions))
+	}
+	var b strings.Builder
+	next := 0
+	for i, r := range editedRegions {
+		s := storedRegions[i]
+		b.WriteString(strings.Join(editedLines[next:r.begin+1], ""))
+		b.WriteString(strings.Join(storedLines[s.begin+1:s.end], ""))
+		next = r.end
+	}
+	b.WriteString(strings.Join(editedLines[next:], ""))
+	return []byte(b.String()), nil
+}
+
+// lockedRegionEdits returns the full content of a region-locked file whose readable
+// portion was edited while locked (see mergeRegions), or nil if it is unchanged.
+func lockedRegionEdits(project *Project, vault *Vault, entry *FileEntry, relPath string) ([]byte, error) {
+	absPath := project.AbsPath(relPath)
+	info, err := os.Lstat(absPath)
+	if err != nil || !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("not a regular file")
+	}
+	if project.isPlaceholderAt(absPath, relPath, entry, info.Size()) {
+		return nil, nil
+	}
+	if info.Size() > redactSizeLimit {
+		return nil, fmt.Errorf("too large to merge (over %d KB)", redactSizeLimit/1024)
+	}
+	edited, err := os.ReadFile(absPath)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := vault.readStored(vault.FilePath(relPath))
+	if err != nil {
+		return nil, fmt.Errorf("reading vault copy: %w", err)
+	}
+	return mergeRegions(relPath, edited, stored, entry.Canary)
+}
+
+// regionsEdited reports whether a region-locked file that is not its placeholder has
+// edits only to its readable portion, which the next lock or unlock accepts (see
+// commitRegionEdits). It does not read the vault copy, so it works on a sealed vault;
+// commitRegionEdits also checks the regions still match the vault's.
+func regionsEdited(project *Project, entry *FileEntry, relPath string) bool {
+	if entry.State != "locked" || entry.Placeholder != PlaceholderRegions {
+		return false
+	}
+	absPath := project.AbsPath(relPath)
+	if info, err := os.Lstat(absPath); err != nil || !info.Mode().IsRegular() || info.Size() > redactSizeLimit {
+		return false
+	}
+	edited, err := os.ReadFile(absPath)
+	if err != nil {
+		return false
+	}
+	_, regions, err := lockedRegions(relPath, edited, entry.Canary)
+	return err == nil && len(regions) > 0
+}
+
+// commitRegionEdits stores a region-locked file's edits to its readable portion in the
+// vault, reassembled with the regions. The edited file then is the file's placeholder,
+// recorded by hash. Returns false if there were no edits.
+func commitRegionEdits(project *Project, vault *Vault, manifest *Manifest, entry *FileEntry, relPath string) (bool, error) {
+	merged, err := lockedRegionEdits(project, vault, entry, relPath)
+	if err != nil || merged == nil {
+		return false, err
+	}
+	placeholderHash, err := HashFile(project.AbsPath(relPath))
+	if err != nil {
+		return false, err
+	}
+	if err := project.journal("relock", relPath, "begin", entry.Hash); err != nil {
+		return false, err
+	}
+	if err := project.journal("relock", relPath, PlaceholderRegions, placeholderHash); err != nil {
+		return false, err
+	}
+
+	tmp := vault.FilePath(relPath) + ".ignlnk-merge"
+	defer os.Remove(tmp)
+	if err := os.WriteFile(tmp, merged, 0o600); err != nil {
+		return false, fmt.Errorf("writing merged file: %w", err)
+	}
+	updated := *entry
+	if _, err := commitVaultCopy(vault, &updated, relPath, tmp); err != nil {
+		return false, err
+	}
+	project.journalStep("relock", relPath, "committed", updated.Hash)
+	project.journalStep("relock", relPath, "placeholder", "")
+	updated.PlaceholderHash = placeholderHash
+	manifest.setEntry(relPath, &updated)
+	warnCapture(project, vault, relPath, "commit")
+	return true, nil
+}
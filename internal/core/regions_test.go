@@ -0,0 +1,129 @@
+package core
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const regionsFile = "#!/bin/sh\n" +
+	"# Mentions of ignlnk:begin in prose are not markers\n" +
+	"echo start\n" +
+	"# ignlnk:begin\n" +
+	"export TOKEN=abc123\n" +
+	"# ignlnk:end\n" +
+	"echo middle\r\n" +
+	"<!-- ignlnk:begin -->\r\n" +
+	"secret\r\n" +
+	"<!-- ignlnk:end -->\r\n" +
+	"echo done\n"
+
+func TestRegionsPlaceholder(t *testing.T) {
+	got, err := RegionsPlaceholder("run.sh", []byte(regionsFile), "")
+	if err != nil {
+		t.Fatalf("RegionsPlaceholder failed: %v", err)
+	}
+	want := "#!/bin/sh\n" +
+		"# Mentions of ignlnk:begin in prose are not markers\n" +
+		"echo start\n" +
+		"# ignlnk:begin\n" +
+		"# [ignlnk:protected] This region is protected by ignlnk. To view it, ask the user to run: ignlnk unlock run.sh\n" +
+		"# ignlnk:end\n" +
+		"echo middle\r\n" +
+		"<!-- ignlnk:begin -->\r\n" +
+		"<!-- [ignlnk:protected] This region is protected by ignlnk. To view it, ask the user to run: ignlnk unlock run.sh -->\r\n" +
+		"<!-- ignlnk:end -->\r\n" +
+		"echo done\n"
+	if string(got) != want {
+		t.Fatalf("expected:\n%q\ngot:\n%q", want, got)
+	}
+
+	for _, bad := range []string{
+		"no markers\n",
+		"# ignlnk:begin\nsecret\n",
+		"secret\n# ignlnk:end\n",
+		"# ignlnk:begin\n# ignlnk:begin\n# ignlnk:end\n",
+	} {
+		if _, err := RegionsPlaceholder("run.sh", []byte(bad), ""); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestMergeRegions(t *testing.T) {
+	placeholder, err := RegionsPlaceholder("run.sh", []byte(regionsFile), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	edited := strings.Replace(string(placeholder), "echo done", "echo finished", 1)
+	merged, err := mergeRegions("run.sh", []byte(edited), []byte(regionsFile), "")
+	if err != nil {
+		t.Fatalf("mergeRegions failed: %v", err)
+	}
+	if want := strings.Replace(regionsFile, "echo done", "echo finished", 1); string(merged) != want {
+		t.Fatalf("expected %q, got %q", want, merged)
+	}
+
+	for name, bad := range map[string]string{
+		"region edited":  strings.Replace(string(placeholder), "This region", "That region", 1),
+		"marker removed": strings.Replace(string(placeholder), "# ignlnk:end\n", "", 1),
+		"region removed": strings.Replace(string(placeholder), "<!-- ignlnk:begin -->", "", 1),
+	} {
+		if _, err := mergeRegions("run.sh", []byte(bad), []byte(regionsFile), ""); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+func TestLockFileRegions(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "run.sh"
+	absPath := p.AbsPath(relPath)
+	if err := os.WriteFile(absPath, []byte(regionsFile), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	entry := m.Files[relPath]
+	if entry.Placeholder != PlaceholderRegions || entry.PlaceholderHash == "" {
+		t.Fatalf("expected markers to select a regions lock, got %+v", entry)
+	}
+	data, err := os.ReadFile(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "abc123") || !strings.Contains(string(data), "echo middle") {
+		t.Fatalf("expected only the regions locked, got %q", data)
+	}
+	if status := FileStatus(p, v, entry, relPath, nil); status != "locked" {
+		t.Fatalf("expected locked, got %q", status)
+	}
+
+	// Edits outside the regions are accepted by the next lock
+	if err := os.WriteFile(absPath, []byte(strings.Replace(string(data), "echo start", "echo begin", 1)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if status := FileStatus(p, v, entry, relPath, nil); status != "edited" {
+		t.Fatalf("expected edited, got %q", status)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+	if status := FileStatus(p, v, m.Files[relPath], relPath, nil); status != "locked" {
+		t.Fatalf("expected locked after accepting edits, got %q", status)
+	}
+	stored, err := os.ReadFile(v.FilePath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Replace(regionsFile, "echo start", "echo begin", 1); string(stored) != want {
+		t.Fatalf("expected vault copy %q, got %q", want, stored)
+	}
+	if hash, _ := HashFile(v.FilePath(relPath)); hash != m.Files[relPath].Hash {
+		t.Fatalf("expected manifest hash updated to %s, got %s", hash, m.Files[relPath].Hash)
+	}
+}
@@ -0,0 +1,128 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ErrUncommittedEdits is returned by RepairFile for an unlocked plaintext-vault file whose
+// vault copy differs from the manifest: edits and corruption are indistinguishable there.
+var ErrUncommittedEdits = errors.New("vault copy differs from manifest because of uncommitted edits — run 'ignlnk commit' to accept or 'ignlnk restore' to roll back")
+
+// QuarantineDir returns the path where unverifiable copies are moved (~/.ignlnk/vault/<uid>.quarantine/).
+func (v *Vault) QuarantineDir() string {
+	return filepath.Join(filepath.Dir(v.Dir), v.UID+".quarantine")
+}
+
+// RepairFile verifies the vault copy and mirror backup of relPath against the manifest hash
+// and heals whichever is wrong from the other, falling back to a history revision with the
+// recorded hash. If no intact copy exists, both are moved to the quarantine directory and an
+// error explains why. Returns a description of the repair, or "" if nothing was needed.
+func RepairFile(project *Project, vault *Vault, manifest *Manifest, relPath string) (string, error) {
+	entry, ok := manifest.Files[relPath]
+	if !ok {
+		return "", fmt.Errorf("file not managed: %s", relPath)
+	}
+	if entry.Dir {
+		return repairDir(vault, entry, relPath)
+	}
+	return repairVaultCopy(vault, entry, relPath)
+}
+
+func repairVaultCopy(vault *Vault, entry *FileEntry, relPath string) (string, error) {
+	vaultPath := vault.FilePath(relPath)
+	backupPath := vault.BackupPath(relPath)
+	vaultOK := storedMatches(vault, vaultPath, entry.Hash)
+	backupOK := storedMatches(vault, backupPath, entry.Hash)
+
+	switch {
+	case vaultOK && backupOK:
+		return "", nil
+
+	case vaultOK:
+		if err := os.MkdirAll(filepath.Dir(backupPath), 0o755); err != nil {
+			return "", fmt.Errorf("creating backup directory: %w", err)
+		}
+		if err := replaceFile(vaultPath, backupPath); err != nil {
+			return "", fmt.Errorf("refreshing backup: %w", err)
+		}
+		return "backup refreshed from vault", nil
+
+	case entry.State == "unlocked" && vault.WorkPath(relPath) == vaultPath && fileExists(vaultPath):
+		return "", ErrUncommittedEdits
+
+	case backupOK:
+		if err := os.MkdirAll(filepath.Dir(vaultPath), 0o755); err != nil {
+			return "", fmt.Errorf("creating vault directory: %w", err)
+		}
+		if err := replaceFile(backupPath, vaultPath); err != nil {
+			return "", fmt.Errorf("restoring vault copy from backup: %w", err)
+		}
+		return "vault restored from backup", nil
+	}
+
+	// Neither copy verifies; a history revision with the recorded hash is the last resort.
+	if obj := vault.objectPath(entry.Hash); storedMatches(vault, obj, entry.Hash) {
+		if err := os.MkdirAll(filepath.Dir(vaultPath), 0o755); err != nil {
+			return "", fmt.Errorf("creating vault directory: %w", err)
+		}
+		if err := os.MkdirAll(filepath.Dir(backupPath), 0o755); err != nil {
+			return "", fmt.Errorf("creating backup directory: %w", err)
+		}
+		if err := replaceFile(obj, vaultPath); err != nil {
+			return "", fmt.Errorf("restoring vault copy from history: %w", err)
+		}
+		if err := replaceFile(obj, backupPath); err != nil {
+			return "", fmt.Errorf("restoring backup from history: %w", err)
+		}
+		return "vault and backup restored from history", nil
+	}
+
+	dir, moved, err := quarantine(vault, relPath)
+	if err != nil {
+		return "", fmt.Errorf("no intact copy of %s (expected %s), and quarantining failed: %w", relPath, entry.Hash, err)
+	}
+	if !moved {
+		return "", fmt.Errorf("no intact copy of %s: vault copy and backup are both missing", relPath)
+	}
+	return "", fmt.Errorf("no intact copy of %s: vault copy and backup both fail verification against %s. Both were moved to %s for inspection; the file cannot be unlocked until good content is restored", relPath, entry.Hash, dir)
+}
+
+// quarantine moves the vault copy and backup of relPath into a timestamped quarantine
+// directory. Returns the directory and whether anything was moved.
+func quarantine(vault *Vault, relPath string) (string, bool, error) {
+	dir := filepath.Join(vault.QuarantineDir(), time.Now().UTC().Format("20060102T150405Z"))
+	moved := false
+	for _, c := range []struct{ src, sub, stopAt string }{
+		{vault.FilePath(relPath), "vault", vault.Dir},
+		{vault.BackupPath(relPath), "backup", vault.BackupDir()},
+	} {
+		if !fileExists(c.src) {
+			continue
+		}
+		dst := filepath.Join(dir, c.sub, filepath.FromSlash(relPath))
+		if err := os.MkdirAll(filepath.Dir(dst), 0o700); err != nil {
+			return dir, moved, err
+		}
+		if err := os.Rename(c.src, dst); err != nil {
+			return dir, moved, err
+		}
+		removeEmptyParents(filepath.Dir(c.src), c.stopAt)
+		moved = true
+	}
+	return dir, moved, nil
+}
+
+// storedMatches reports whether the vault-format file at path exists and has the given plaintext hash.
+func storedMatches(vault *Vault, path, hash string) bool {
+	got, err := vault.hashStored(path)
+	return err == nil && got == hash
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
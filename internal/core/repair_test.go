@@ -0,0 +1,98 @@
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+// lockForRepair locks relPath with content and disables history so the
+// backup is the only redundant copy.
+func lockForRepair(t *testing.T, p *Project, v *Vault, m *Manifest, relPath, content string) {
+	t.Helper()
+	keep := 0
+	p.config = &Config{HistoryKeep: &keep}
+	if err := os.WriteFile(p.AbsPath(relPath), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+}
+
+func TestRepairFileHealsVaultFromBackup(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	lockForRepair(t, p, v, m, relPath, "good content")
+	if err := os.WriteFile(v.FilePath(relPath), []byte("bit rot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	action, err := RepairFile(p, v, m, relPath)
+	if err != nil || action == "" {
+		t.Fatalf("expected repair, got action=%q err=%v", action, err)
+	}
+	got, err := os.ReadFile(v.FilePath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "good content" {
+		t.Fatalf("expected healed vault copy, got %q", got)
+	}
+
+	if action, err := RepairFile(p, v, m, relPath); err != nil || action != "" {
+		t.Fatalf("expected no-op after repair, got action=%q err=%v", action, err)
+	}
+}
+
+func TestRepairFileQuarantinesWhenNoIntactCopy(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	lockForRepair(t, p, v, m, relPath, "good content")
+	for _, path := range []string{v.FilePath(relPath), v.BackupPath(relPath)} {
+		if err := os.WriteFile(path, []byte("bit rot"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := RepairFile(p, v, m, relPath); err == nil {
+		t.Fatal("expected RepairFile to fail with no intact copy")
+	}
+	if fileExists(v.FilePath(relPath)) || fileExists(v.BackupPath(relPath)) {
+		t.Fatal("expected both copies moved out of the vault")
+	}
+	entries, err := os.ReadDir(v.QuarantineDir())
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected one quarantine directory, got %v (err %v)", entries, err)
+	}
+}
+
+func TestUnlockFileAutoRepair(t *testing.T) {
+	if err := CheckSymlinkSupport(t.TempDir()); err != nil {
+		t.Skip("symlinks not supported:", err)
+	}
+
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	lockForRepair(t, p, v, m, relPath, "good content")
+	p.config.AutoRepair = true
+	if err := os.Remove(v.FilePath(relPath)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := UnlockFile(p, v, m, relPath); err != nil {
+		t.Fatalf("UnlockFile with auto-repair failed: %v", err)
+	}
+	got, err := os.ReadFile(p.AbsPath(relPath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "good content" {
+		t.Fatalf("expected repaired content through symlink, got %q", got)
+	}
+}
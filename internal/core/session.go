@@ -0,0 +1,143 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/natefinch/atomic"
+)
+
+// Session event kinds.
+const (
+	EventUnlockBlocked       = "unlock-blocked"       // Unlock refused by the session
+	EventUnlockOverride      = "unlock-override"      // Unlock allowed with --override and a reason
+	EventPlaceholderModified = "placeholder-modified" // Locked file no longer its intact placeholder
+	EventPlaceholderRestored = "placeholder-restored" // A modified placeholder is intact again
+)
+
+// Session represents .ignlnk/session.json: an agent session during which every file stays
+// locked and unlock attempts and placeholder modifications are recorded.
+type Session struct {
+	Name      string            `json:"name"`
+	StartedAt string            `json:"startedAt"`          // ISO 8601
+	Events    []SessionEvent    `json:"events"`             // In order of occurrence
+	Modified  map[string]string `json:"modified,omitempty"` // Path -> status, for placeholders currently modified
+}
+
+// SessionEvent is one recorded occurrence during a session.
+type SessionEvent struct {
+	Time   string `json:"time"` // ISO 8601
+	Kind   string `json:"kind"`
+	Path   string `json:"path,omitempty"`
+	Detail string `json:"detail,omitempty"` // Override reason, or the modified file's status
+}
+
+func (p *Project) sessionPath() string {
+	return filepath.Join(p.IgnlnkDir, "session.json")
+}
+
+// LoadSession reads the active session; nil if there is none.
+func (p *Project) LoadSession() (*Session, error) {
+	data, err := os.ReadFile(p.sessionPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading session: %w", err)
+	}
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing session: %w", err)
+	}
+	return &s, nil
+}
+
+// StartSession creates and saves a new active session. Fails if one is already active.
+func (p *Project) StartSession(name string) (*Session, error) {
+	if active, err := p.LoadSession(); err != nil {
+		return nil, err
+	} else if active != nil {
+		return nil, fmt.Errorf("session %q is already active (started %s) — run 'ignlnk session stop' first", active.Name, active.StartedAt)
+	}
+	s := &Session{Name: name, StartedAt: time.Now().UTC().Format(time.RFC3339), Events: []SessionEvent{}}
+	return s, p.SaveSession(s)
+}
+
+// SaveSession writes the session atomically. Callers hold the manifest lock.
+func (p *Project) SaveSession(s *Session) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling session: %w", err)
+	}
+	if err := atomic.WriteFile(p.sessionPath(), strings.NewReader(string(data)+"\n")); err != nil {
+		return fmt.Errorf("writing session: %w", err)
+	}
+	return nil
+}
+
+// EndSession removes the active session.
+func (p *Project) EndSession() error {
+	if err := os.Remove(p.sessionPath()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing session: %w", err)
+	}
+	return nil
+}
+
+func (s *Session) record(kind, relPath, detail string) {
+	s.Events = append(s.Events, SessionEvent{
+		Time:   time.Now().UTC().Format(time.RFC3339),
+		Kind:   kind,
+		Path:   relPath,
+		Detail: detail,
+	})
+}
+
+// AllowUnlock records an attempt to unlock relPath and reports whether it may proceed:
+// only with a non-empty override reason. Caller saves the session.
+func (s *Session) AllowUnlock(relPath, override string) bool {
+	if strings.TrimSpace(override) == "" {
+		s.record(EventUnlockBlocked, relPath, "")
+		return false
+	}
+	s.record(EventUnlockOverride, relPath, override)
+	return true
+}
+
+// Scan records placeholders modified since the last scan (and modified ones that are
+// intact again). Returns the number of new events; caller saves the session if any.
+func (s *Session) Scan(project *Project, vault *Vault, manifest *Manifest) int {
+	keys := make([]string, 0, len(manifest.Files))
+	for k := range manifest.Files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	recorded := 0
+	for _, relPath := range keys {
+		entry := manifest.Files[relPath]
+		if entry.State != "locked" {
+			continue
+		}
+		status := FileStatus(project, vault, entry, relPath, nil)
+		prev, flagged := s.Modified[relPath]
+		switch {
+		case status != "locked" && status != prev:
+			if s.Modified == nil {
+				s.Modified = make(map[string]string)
+			}
+			s.Modified[relPath] = status
+			s.record(EventPlaceholderModified, relPath, status)
+			recorded++
+		case status == "locked" && flagged:
+			delete(s.Modified, relPath)
+			s.record(EventPlaceholderRestored, relPath, "")
+			recorded++
+		}
+	}
+	return recorded
+}
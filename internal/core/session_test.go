@@ -0,0 +1,89 @@
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSessionRecordsActivity(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	relPath := "secret.txt"
+	absPath := p.AbsPath(relPath)
+	if err := os.WriteFile(absPath, []byte("original"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LockFile(p, v, m, relPath, false); err != nil {
+		t.Fatalf("LockFile failed: %v", err)
+	}
+
+	if s, err := p.LoadSession(); err != nil || s != nil {
+		t.Fatalf("expected no session, got %+v, %v", s, err)
+	}
+	s, err := p.StartSession("agent")
+	if err != nil {
+		t.Fatalf("StartSession failed: %v", err)
+	}
+	if _, err := p.StartSession("again"); err == nil {
+		t.Fatal("expected second StartSession to fail")
+	}
+
+	if s.AllowUnlock(relPath, "  ") {
+		t.Fatal("expected unlock without a reason to be blocked")
+	}
+	if !s.AllowUnlock(relPath, "rotate key") {
+		t.Fatal("expected unlock with a reason to be allowed")
+	}
+	if n := s.Scan(p, v, m); n != 0 {
+		t.Fatalf("expected nothing to record for intact placeholder, got %d", n)
+	}
+
+	// Modify the placeholder; recorded once, then again when restored
+	placeholder, err := os.ReadFile(absPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(absPath, []byte("overwritten"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Scan(p, v, m); n != 1 {
+		t.Fatalf("expected 1 event for modified placeholder, got %d", n)
+	}
+	if n := s.Scan(p, v, m); n != 0 {
+		t.Fatalf("expected modification recorded only once, got %d", n)
+	}
+	if err := os.WriteFile(absPath, placeholder, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.Scan(p, v, m); n != 1 || len(s.Modified) != 0 {
+		t.Fatalf("expected restore recorded, got %d events, modified %v", n, s.Modified)
+	}
+
+	if err := p.SaveSession(s); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+	loaded, err := p.LoadSession()
+	if err != nil {
+		t.Fatalf("LoadSession failed: %v", err)
+	}
+	want := []string{EventUnlockBlocked, EventUnlockOverride, EventPlaceholderModified, EventPlaceholderRestored}
+	if len(loaded.Events) != len(want) {
+		t.Fatalf("expected %d events, got %+v", len(want), loaded.Events)
+	}
+	for i, kind := range want {
+		if loaded.Events[i].Kind != kind || loaded.Events[i].Path != relPath {
+			t.Errorf("event %d: expected %s for %s, got %+v", i, kind, relPath, loaded.Events[i])
+		}
+	}
+	if loaded.Events[1].Detail != "rotate key" {
+		t.Errorf("expected override reason recorded, got %q", loaded.Events[1].Detail)
+	}
+
+	if err := p.EndSession(); err != nil {
+		t.Fatalf("EndSession failed: %v", err)
+	}
+	if s, err := p.LoadSession(); err != nil || s != nil {
+		t.Fatalf("expected session ended, got %+v, %v", s, err)
+	}
+}
@@ -0,0 +1,123 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/natefinch/atomic"
+)
+
+// racyWindow is how recently a file may have changed and still be cached. A write
+// landing within the same mtime tick as the stat would otherwise go unnoticed.
+const racyWindow = 2 * time.Second
+
+// StatCache represents .ignlnk/statcache.json: hashes of unlocked files' live copies,
+// keyed by manifest path and valid while the target's stat signature is unchanged,
+// like git's index. Lets status skip re-hashing unchanged files.
+type StatCache struct {
+	Version int                   `json:"version"`
+	Files   map[string]*statEntry `json:"files"`
+
+	path  string
+	dirty bool
+}
+
+// statEntry is the stat signature a cached hash was computed for.
+type statEntry struct {
+	Size  int64  `json:"size"`
+	Mtime int64  `json:"mtime"`           // Unix nanoseconds
+	Ctime int64  `json:"ctime,omitempty"` // Unix nanoseconds, where the platform provides it
+	Inode uint64 `json:"inode,omitempty"` // Where the platform provides it
+	Hash  string `json:"hash"`
+}
+
+// LoadStatCache reads the stat cache. A missing or unreadable cache is treated as empty.
+func (p *Project) LoadStatCache() *StatCache {
+	c := &StatCache{Version: 1, Files: make(map[string]*statEntry), path: filepath.Join(p.IgnlnkDir, "statcache.json")}
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return c
+	}
+	var loaded StatCache
+	if err := json.Unmarshal(data, &loaded); err != nil || loaded.Files == nil {
+		return c
+	}
+	c.Files = loaded.Files
+	return c
+}
+
+// hash returns the hash of path, cached under key. A nil cache always hashes.
+func (c *StatCache) hash(key, path string) (string, error) {
+	if c == nil {
+		return HashFile(path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	sig := statSignature(info)
+	if cached, ok := c.Files[key]; ok && cached.Hash != "" && cached.matches(sig) {
+		return cached.Hash, nil
+	}
+
+	hash, err := HashFile(path)
+	if err != nil {
+		return "", err
+	}
+	if time.Since(info.ModTime()) > racyWindow {
+		sig.Hash = hash
+		c.Files[key] = &sig
+		c.dirty = true
+	} else if _, ok := c.Files[key]; ok {
+		delete(c.Files, key)
+		c.dirty = true
+	}
+	return hash, nil
+}
+
+func (e *statEntry) matches(sig statEntry) bool {
+	return e.Size == sig.Size && e.Mtime == sig.Mtime && e.Ctime == sig.Ctime && e.Inode == sig.Inode
+}
+
+func statSignature(info os.FileInfo) statEntry {
+	sig := statEntry{Size: info.Size(), Mtime: info.ModTime().UnixNano()}
+	sig.Inode, sig.Ctime = fileIdentity(info)
+	return sig
+}
+
+// Clear drops every entry, so each file is hashed and the cache rebuilt.
+func (c *StatCache) Clear() {
+	c.Files = make(map[string]*statEntry)
+	c.dirty = true
+}
+
+// Prune drops entries for paths that are no longer unlocked in manifest.
+func (c *StatCache) Prune(manifest *Manifest) {
+	for key := range c.Files {
+		if entry, ok := manifest.Files[key]; !ok || !entry.Unlocked() {
+			delete(c.Files, key)
+			c.dirty = true
+		}
+	}
+}
+
+// Save writes the cache if it changed. The cache is an optimization, so callers may
+// ignore errors.
+func (c *StatCache) Save() error {
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("marshaling stat cache: %w", err)
+	}
+	if err := atomic.WriteFile(c.path, strings.NewReader(string(data)+"\n")); err != nil {
+		return fmt.Errorf("writing stat cache: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestStatCacheSkipsUnchangedFiles(t *testing.T) {
+	p, _, _, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	path := p.AbsPath("secret.txt")
+	if err := os.WriteFile(path, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// Age the file past the racy window so its hash is cached
+	old := time.Now().Add(-time.Minute)
+	if err := os.Chtimes(path, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	cache := p.LoadStatCache()
+	want, err := cache.hash("secret.txt", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cache.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A poisoned entry with a matching signature is trusted: proof the file was not re-hashed
+	cache = p.LoadStatCache()
+	cache.Files["secret.txt"].Hash = "sha256:cached"
+	if got, err := cache.hash("secret.txt", path); err != nil || got != "sha256:cached" {
+		t.Fatalf("expected cache hit, got %q, %v", got, err)
+	}
+
+	// Any change to the file invalidates the entry
+	if err := os.WriteFile(path, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := cache.hash("secret.txt", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == "sha256:cached" || got == want {
+		t.Fatalf("expected fresh hash after edit, got %q", got)
+	}
+	if _, ok := cache.Files["secret.txt"]; ok {
+		t.Fatal("expected recently modified file not to be cached")
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofrs/flock"
@@ -23,14 +24,26 @@ type Index struct {
 
 // ProjectEntry maps a UID to a project root
 type ProjectEntry struct {
-	Root         string `json:"root"`
-	RegisteredAt string `json:"registeredAt"`
+	Root         string           `json:"root"`
+	RegisteredAt string           `json:"registeredAt"`
+	Encryption   *VaultEncryption `json:"encryption,omitempty"` // nil = plaintext vault
+}
+
+// ProjectInfo represents .ignlnk/project.json. It records the vault UID in-project
+// so a moved or renamed project can still find its vault.
+type ProjectInfo struct {
+	Version int    `json:"version"`
+	UID     string `json:"uid"`
 }
 
 // Vault represents a resolved vault for a specific project
 type Vault struct {
-	UID string // Short random hex ID
-	Dir string // ~/.ignlnk/vault/<uid>/
+	UID        string           // Short random hex ID
+	Dir        string           // ~/.ignlnk/vault/<uid>/
+	Encryption *VaultEncryption // nil = plaintext vault
+
+	key     []byte     // Derived key, set by Unseal
+	namesMu sync.Mutex // Guards the hidden names file (see HiddenNames)
 }
 
 // IgnlnkHome returns the path to ~/.ignlnk/, creating it if needed.
@@ -134,14 +147,10 @@ func RegisterProject(projectRoot string) (*Vault, error) {
 	// Check if already registered
 	for uid, entry := range idx.Projects {
 		if normalizePath(entry.Root) == normalizePath(absRoot) {
-			home, err := IgnlnkHome()
-			if err != nil {
+			if err := saveProjectInfo(absRoot, uid); err != nil {
 				return nil, err
 			}
-			return &Vault{
-				UID: uid,
-				Dir: filepath.Join(home, "vault", uid),
-			}, nil
+			return vaultFor(uid, entry)
 		}
 	}
 
@@ -163,6 +172,9 @@ func RegisterProject(projectRoot string) (*Vault, error) {
 	if err := SaveIndex(idx); err != nil {
 		return nil, err
 	}
+	if err := saveProjectInfo(absRoot, uid); err != nil {
+		return nil, err
+	}
 
 	return &Vault{UID: uid, Dir: vaultDir}, nil
 }
@@ -181,20 +193,167 @@ func ResolveVault(projectRoot string) (*Vault, error) {
 
 	for uid, entry := range idx.Projects {
 		if normalizePath(entry.Root) == normalizePath(absRoot) {
-			home, err := IgnlnkHome()
-			if err != nil {
+			// Backfill project.json for projects initialized before it existed (best-effort)
+			if info, err := loadProjectInfo(absRoot); err == nil && info == nil {
+				saveProjectInfo(absRoot, uid)
+			}
+			return vaultFor(uid, entry)
+		}
+	}
+
+	// Root not in the index: the project may have been moved or renamed.
+	// Fall back to the UID recorded in .ignlnk/project.json.
+	info, err := loadProjectInfo(absRoot)
+	if err != nil {
+		return nil, err
+	}
+	if info != nil {
+		if entry, ok := idx.Projects[info.UID]; ok {
+			if err := checkNotCopy(info.UID, entry); err != nil {
 				return nil, err
 			}
-			return &Vault{
-				UID: uid,
-				Dir: filepath.Join(home, "vault", uid),
-			}, nil
+			fmt.Fprintf(os.Stderr, "warning: project moved from %s — run 'ignlnk relocate' to update the index\n", filepath.FromSlash(entry.Root))
+			return vaultFor(info.UID, entry)
 		}
 	}
 
 	return nil, fmt.Errorf("project not registered — run 'ignlnk init' first")
 }
 
+// RelocateProject points the index entry for this project's vault at projectRoot.
+// The UID comes from .ignlnk/project.json, or from uid when given (for projects
+// initialized before project.json existed). Returns the vault and the previous root.
+func RelocateProject(projectRoot, uid string) (*Vault, string, error) {
+	absRoot, err := filepath.Abs(projectRoot)
+	if err != nil {
+		return nil, "", fmt.Errorf("resolving project root: %w", err)
+	}
+
+	if uid == "" {
+		info, err := loadProjectInfo(absRoot)
+		if err != nil {
+			return nil, "", err
+		}
+		if info == nil {
+			return nil, "", fmt.Errorf("no .ignlnk/project.json — pass --uid with the vault ID from ~/.ignlnk/index.json")
+		}
+		uid = info.UID
+	}
+
+	unlock, err := LockIndex()
+	if err != nil {
+		return nil, "", err
+	}
+	defer unlock()
+
+	idx, err := LoadIndex()
+	if err != nil {
+		return nil, "", err
+	}
+	entry, ok := idx.Projects[uid]
+	if !ok {
+		return nil, "", fmt.Errorf("vault %s not found in index", uid)
+	}
+	oldRoot := entry.Root
+
+	if normalizePath(oldRoot) != normalizePath(absRoot) {
+		if err := checkNotCopy(uid, entry); err != nil {
+			return nil, "", err
+		}
+		for otherUID, other := range idx.Projects {
+			if otherUID != uid && normalizePath(other.Root) == normalizePath(absRoot) {
+				return nil, "", fmt.Errorf("%s is already registered with vault %s", absRoot, otherUID)
+			}
+		}
+		entry.Root = absRoot
+		if err := SaveIndex(idx); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := saveProjectInfo(absRoot, uid); err != nil {
+		return nil, "", err
+	}
+	vault, err := vaultFor(uid, entry)
+	if err != nil {
+		return nil, "", err
+	}
+	return vault, oldRoot, nil
+}
+
+// checkNotCopy refuses to adopt a vault whose registered root still exists and claims
+// the same UID — that means the current directory is a copy, not a move.
+func checkNotCopy(uid string, entry *ProjectEntry) error {
+	info, err := loadProjectInfo(entry.Root)
+	if err == nil && info != nil && info.UID == uid {
+		return fmt.Errorf("vault %s belongs to %s, which still exists — this project looks like a copy. Remove its .ignlnk/ and run 'ignlnk init' to give it its own vault", uid, filepath.FromSlash(entry.Root))
+	}
+	return nil
+}
+
+// vaultFor builds the Vault for an index entry.
+func vaultFor(uid string, entry *ProjectEntry) (*Vault, error) {
+	home, err := IgnlnkHome()
+	if err != nil {
+		return nil, err
+	}
+	return &Vault{
+		UID:        uid,
+		Dir:        filepath.Join(home, "vault", uid),
+		Encryption: entry.Encryption,
+	}, nil
+}
+
+// loadProjectInfo reads <root>/.ignlnk/project.json. Returns nil, nil if it doesn't exist.
+func loadProjectInfo(root string) (*ProjectInfo, error) {
+	data, err := os.ReadFile(filepath.Join(root, ".ignlnk", "project.json"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading project.json: %w", err)
+	}
+	var info ProjectInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("parsing project.json: %w", err)
+	}
+	return &info, nil
+}
+
+// saveProjectInfo writes <root>/.ignlnk/project.json atomically.
+func saveProjectInfo(root, uid string) error {
+	data, err := json.MarshalIndent(&ProjectInfo{Version: 1, UID: uid}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling project.json: %w", err)
+	}
+	data = append(data, '\n')
+	r := strings.NewReader(string(data))
+	if err := atomic.WriteFile(filepath.Join(root, ".ignlnk", "project.json"), r); err != nil {
+		return fmt.Errorf("writing project.json: %w", err)
+	}
+	return nil
+}
+
+// SetVaultEncryption records (or clears, when enc is nil) the encryption parameters for a vault.
+func SetVaultEncryption(uid string, enc *VaultEncryption) error {
+	unlock, err := LockIndex()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	idx, err := LoadIndex()
+	if err != nil {
+		return err
+	}
+	entry, ok := idx.Projects[uid]
+	if !ok {
+		return fmt.Errorf("vault %s not found in index", uid)
+	}
+	entry.Encryption = enc
+	return SaveIndex(idx)
+}
+
 // FilePath returns the OS-native vault path for a given manifest relative path.
 func (v *Vault) FilePath(relPath string) string {
 	return filepath.Join(v.Dir, filepath.FromSlash(relPath))
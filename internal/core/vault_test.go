@@ -0,0 +1,75 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// setupRegisteredProject initializes and registers a project under a temp HOME.
+func setupRegisteredProject(t *testing.T) (string, *Vault) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("USERPROFILE", os.Getenv("HOME"))
+	root := filepath.Join(t.TempDir(), "project")
+	if _, err := InitProject(root); err != nil {
+		t.Fatal(err)
+	}
+	v, err := RegisterProject(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return root, v
+}
+
+func TestResolveVaultAfterMove(t *testing.T) {
+	root, v := setupRegisteredProject(t)
+
+	moved := filepath.Join(filepath.Dir(root), "renamed")
+	if err := os.Rename(root, moved); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ResolveVault(moved)
+	if err != nil {
+		t.Fatalf("ResolveVault after move failed: %v", err)
+	}
+	if got.UID != v.UID {
+		t.Fatalf("expected vault %s, got %s", v.UID, got.UID)
+	}
+
+	relocated, oldRoot, err := RelocateProject(moved, "")
+	if err != nil {
+		t.Fatalf("RelocateProject failed: %v", err)
+	}
+	if relocated.UID != v.UID || oldRoot != root {
+		t.Fatalf("unexpected relocate result: uid=%s oldRoot=%s", relocated.UID, oldRoot)
+	}
+
+	idx, err := LoadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if idx.Projects[v.UID].Root != moved {
+		t.Fatalf("expected index root %s, got %s", moved, idx.Projects[v.UID].Root)
+	}
+}
+
+func TestResolveVaultRefusesCopy(t *testing.T) {
+	root, _ := setupRegisteredProject(t)
+
+	copyRoot := filepath.Join(filepath.Dir(root), "copy")
+	if err := os.MkdirAll(filepath.Join(copyRoot, ".ignlnk"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyFile(filepath.Join(root, ".ignlnk", "project.json"), filepath.Join(copyRoot, ".ignlnk", "project.json")); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ResolveVault(copyRoot); err == nil {
+		t.Fatal("expected ResolveVault to refuse a copied project")
+	}
+	if _, _, err := RelocateProject(copyRoot, ""); err == nil {
+		t.Fatal("expected RelocateProject to refuse a copied project")
+	}
+}
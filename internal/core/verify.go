@@ -0,0 +1,180 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Verify issue categories, in report order.
+const (
+	IssueVaultMissing       = "vault-missing"       // Vault copy does not exist
+	IssueVaultMismatch      = "vault-mismatch"      // Vault copy does not match the recorded hash
+	IssueBackupMissing      = "backup-missing"      // Mirror backup does not exist
+	IssueBackupMismatch     = "backup-mismatch"     // Mirror backup does not match the recorded hash
+	IssuePlaceholderInvalid = "placeholder-invalid" // Locked file is not its exact placeholder
+	IssueSymlinkInvalid     = "symlink-invalid"     // Unlocked file is not a symlink to the vault (or not a copy)
+	IssueDirty              = "dirty"               // Unlocked edits not yet committed
+	IssueOrphan             = "orphan"              // Vault or backup file with no manifest entry
+	IssueHistoryCorrupt     = "history-corrupt"     // History object does not match its hash
+)
+
+// IssueCategories lists every verify category in report order.
+var IssueCategories = []string{
+	IssueVaultMissing,
+	IssueVaultMismatch,
+	IssueBackupMissing,
+	IssueBackupMismatch,
+	IssuePlaceholderInvalid,
+	IssueSymlinkInvalid,
+	IssueDirty,
+	IssueOrphan,
+	IssueHistoryCorrupt,
+}
+
+// Issue is a single verify finding.
+type Issue struct {
+	Category string `json:"category"`
+	Path     string `json:"path"` // Manifest relative path (forward slash)
+	Detail   string `json:"detail,omitempty"`
+}
+
+// VerifyReport is the result of VerifyProject.
+type VerifyReport struct {
+	Checked int     `json:"checked"` // Number of manifest entries checked
+	Issues  []Issue `json:"issues"`
+}
+
+// VerifyProject hashes every managed file's vault copy and mirror backup against the
+// manifest, checks the working tree for exact placeholders and correct symlinks, and
+// looks for orphaned vault files and corrupted history objects. Read-only.
+func VerifyProject(project *Project, vault *Vault, manifest *Manifest) *VerifyReport {
+	report := &VerifyReport{Issues: []Issue{}}
+	add := func(category, relPath, detail string) {
+		report.Issues = append(report.Issues, Issue{Category: category, Path: relPath, Detail: detail})
+	}
+
+	keys := make([]string, 0, len(manifest.Files))
+	for k := range manifest.Files {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, relPath := range keys {
+		entry := manifest.Files[relPath]
+		report.Checked++
+		if entry.Dir {
+			verifyDir(project, vault, entry, relPath, add)
+			continue
+		}
+		vaultPath := vault.FilePath(relPath)
+		workPath := vault.WorkPath(relPath)
+		dirtyHint := "run 'ignlnk commit' to accept"
+		if entry.ReadOnly {
+			dirtyHint = "changed during read-only unlock; discarded on re-lock"
+		}
+
+		// Vault copy. A plaintext vault's unlocked copy is edited in place, so a
+		// mismatch there is uncommitted work rather than corruption.
+		if _, err := os.Stat(vaultPath); err != nil {
+			add(IssueVaultMissing, relPath, "")
+		} else if hash, err := vault.hashStored(vaultPath); err != nil {
+			add(IssueVaultMismatch, relPath, err.Error())
+		} else if hash != entry.Hash {
+			if entry.State == "unlocked" && workPath == vaultPath {
+				add(IssueDirty, relPath, dirtyHint)
+			} else {
+				add(IssueVaultMismatch, relPath, "expected "+entry.Hash+", got "+hash)
+			}
+		}
+
+		// Mirror backup
+		backupPath := vault.BackupPath(relPath)
+		if _, err := os.Stat(backupPath); err != nil {
+			add(IssueBackupMissing, relPath, "")
+		} else if hash, err := vault.hashStored(backupPath); err != nil {
+			add(IssueBackupMismatch, relPath, err.Error())
+		} else if hash != entry.Hash {
+			add(IssueBackupMismatch, relPath, "expected "+entry.Hash+", got "+hash)
+		}
+
+		// Working tree
+		absPath := livePath(project, vault, entry, relPath)
+		info, err := os.Lstat(absPath)
+		switch {
+		case entry.State == "locked":
+			if err != nil {
+				add(IssuePlaceholderInvalid, relPath, "placeholder missing")
+			} else if !info.Mode().IsRegular() || !project.isPlaceholderAt(absPath, relPath, entry, info.Size()) &&
+				!regionsEdited(project, entry, relPath) {
+				add(IssuePlaceholderInvalid, relPath, "not the exact ignlnk placeholder")
+			}
+		case entry.State == "unlocked":
+			if err != nil || info.Mode()&os.ModeSymlink == 0 {
+				add(IssueSymlinkInvalid, relPath, "not a symlink")
+			} else if target, err := os.Readlink(absPath); err != nil || target != workPath {
+				add(IssueSymlinkInvalid, relPath, "points at "+target+", expected "+workPath+" (run 'ignlnk relocate')")
+			} else if workPath != vaultPath {
+				if hash, err := HashFile(workPath); err == nil && hash != entry.Hash {
+					add(IssueDirty, relPath, dirtyHint)
+				}
+			}
+		case entry.State == "unlocked-copy":
+			if err != nil || !info.Mode().IsRegular() || project.isPlaceholderAt(absPath, relPath, entry, info.Size()) {
+				add(IssueSymlinkInvalid, relPath, "not an unlocked copy")
+			} else if hash, err := HashFile(absPath); err == nil && hash != entry.Hash {
+				add(IssueDirty, relPath, dirtyHint)
+			}
+		}
+	}
+
+	// Orphans: vault or backup files the manifest does not know about
+	for _, root := range []struct{ dir, label string }{{vault.Dir, "vault"}, {vault.BackupDir(), "backup"}} {
+		filepath.WalkDir(root.dir, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			rel, err := filepath.Rel(root.dir, path)
+			if err != nil {
+				return nil
+			}
+			rel = filepath.ToSlash(rel)
+			if d.IsDir() {
+				// A managed directory's copy is checked as a whole above
+				if entry, ok := manifest.Files[rel]; ok && entry.Dir {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if _, ok := manifest.Files[rel]; !ok {
+				add(IssueOrphan, rel, "in "+root.label)
+			}
+			return nil
+		})
+	}
+
+	// History objects are named by their plaintext hash
+	if entries, err := os.ReadDir(vault.objectsDir()); err == nil {
+		for _, e := range entries {
+			hash, err := vault.hashStored(filepath.Join(vault.objectsDir(), e.Name()))
+			if err != nil || strings.TrimPrefix(hash, "sha256:") != e.Name() {
+				add(IssueHistoryCorrupt, e.Name(), "")
+			}
+		}
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return categoryRank(report.Issues[i].Category) < categoryRank(report.Issues[j].Category)
+	})
+	return report
+}
+
+func categoryRank(category string) int {
+	for i, c := range IssueCategories {
+		if c == category {
+			return i
+		}
+	}
+	return len(IssueCategories)
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"os"
+	"testing"
+)
+
+func TestVerifyProject(t *testing.T) {
+	p, v, m, cleanup := setupLockFileTest(t)
+	defer cleanup()
+
+	for _, name := range []string{"clean.txt", "corrupt.txt", "nobackup.txt", "tampered.txt"} {
+		if err := os.WriteFile(p.AbsPath(name), []byte("content of "+name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := LockFile(p, v, m, name, false); err != nil {
+			t.Fatalf("LockFile %s failed: %v", name, err)
+		}
+	}
+
+	if err := os.WriteFile(v.FilePath("corrupt.txt"), []byte("bit rot"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(v.BackupPath("nobackup.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p.AbsPath("tampered.txt"), []byte("agent was here"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(v.FilePath("stray.txt"), []byte("stray"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report := VerifyProject(p, v, m)
+	if report.Checked != 4 {
+		t.Fatalf("expected 4 files checked, got %d", report.Checked)
+	}
+
+	want := map[string]string{
+		"corrupt.txt":  IssueVaultMismatch,
+		"nobackup.txt": IssueBackupMissing,
+		"tampered.txt": IssuePlaceholderInvalid,
+		"stray.txt":    IssueOrphan,
+	}
+	if len(report.Issues) != len(want) {
+		t.Fatalf("expected %d issues, got %+v", len(want), report.Issues)
+	}
+	for _, issue := range report.Issues {
+		if want[issue.Path] != issue.Category {
+			t.Errorf("unexpected issue %+v", issue)
+		}
+	}
+}
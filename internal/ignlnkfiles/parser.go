@@ -1,6 +1,8 @@
 package ignlnkfiles
 
 import (
+	"errors"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,14 +12,49 @@ import (
 	"github.com/user/ignlnk/internal/core"
 )
 
+// Patterns are the compiled patterns of a .ignlnkfiles file.
+type Patterns struct {
+	files *ignore.GitIgnore // Every pattern, matched against file paths
+	dirs  *ignore.GitIgnore // Trailing-slash patterns without the slash, matched against directories
+}
+
 // Load reads a .ignlnkfiles file and compiles the patterns.
-func Load(path string) (*ignore.GitIgnore, error) {
-	return ignore.CompileIgnoreFile(path)
+func Load(path string) (*Patterns, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(strings.Split(string(data), "\n")...), nil
+}
+
+// Compile compiles .ignlnkfiles pattern lines.
+func Compile(lines ...string) *Patterns {
+	var dirLines []string
+	for _, line := range lines {
+		line = strings.TrimRight(line, " \t\r")
+		if strings.HasSuffix(line, "/") && !strings.HasPrefix(line, "#") {
+			dirLines = append(dirLines, strings.TrimSuffix(line, "/"))
+		}
+	}
+	return &Patterns{
+		files: ignore.CompileIgnoreLines(lines...),
+		dirs:  ignore.CompileIgnoreLines(dirLines...),
+	}
+}
+
+// MatchesPath reports whether the file at relPath (forward slash) matches the patterns.
+func (p *Patterns) MatchesPath(relPath string) bool {
+	return p.files.MatchesPath(relPath)
 }
 
 // DiscoverFiles walks the project tree and returns all files matching .ignlnkfiles patterns.
-// Excludes .ignlnk/ directory and already-managed files.
-func DiscoverFiles(projectRoot string, ignorer *ignore.GitIgnore, manifest *core.Manifest) ([]string, error) {
+// Excludes .ignlnk/ directory and already-managed files and directories.
+//
+// A directory matched by a trailing-slash pattern (e.g. "secrets/") is returned itself,
+// to be locked as one unit, if every file in it matches the patterns (no negation
+// excludes one), none is managed yet, and it holds only regular files and directories.
+// Otherwise its files are discovered one by one.
+func DiscoverFiles(projectRoot string, patterns *Patterns, manifest *core.Manifest) ([]string, error) {
 	var matches []string
 
 	err := filepath.WalkDir(projectRoot, func(path string, d os.DirEntry, err error) error {
@@ -38,6 +75,19 @@ func DiscoverFiles(projectRoot string, ignorer *ignore.GitIgnore, manifest *core
 			if strings.HasPrefix(name, ".") && name != "." {
 				return filepath.SkipDir
 			}
+			if rel == "." {
+				return nil
+			}
+			// A managed directory's placeholder is not discovered again
+			if entry, ok := manifest.Files[rel]; ok && entry.Dir {
+				return filepath.SkipDir
+			}
+			// Only the directory a pattern names, not every directory under it
+			if patterns.dirs.MatchesPath(rel) && !patterns.dirs.MatchesPath(filepath.ToSlash(filepath.Dir(rel))) &&
+				lockableDir(path, rel, patterns, manifest) {
+				matches = append(matches, rel)
+				return filepath.SkipDir
+			}
 			return nil
 		}
 
@@ -52,7 +102,7 @@ func DiscoverFiles(projectRoot string, ignorer *ignore.GitIgnore, manifest *core
 		}
 
 		// Check against patterns
-		if ignorer.MatchesPath(rel) {
+		if patterns.MatchesPath(rel) {
 			matches = append(matches, rel)
 		}
 
@@ -61,3 +111,31 @@ func DiscoverFiles(projectRoot string, ignorer *ignore.GitIgnore, manifest *core
 
 	return matches, err
 }
+
+// errNotLockable stops lockableDir's walk at the first file that rules the directory out.
+var errNotLockable = errors.New("directory cannot be locked as a unit")
+
+// lockableDir reports whether directory dir (project path rel) can be locked as one unit:
+// every file in it matches the patterns and is unmanaged, and nothing in it is a symlink
+// or special file.
+func lockableDir(dir, rel string, patterns *Patterns, manifest *core.Manifest) bool {
+	for relPath := range manifest.Files {
+		if strings.HasPrefix(relPath, rel+"/") {
+			return false
+		}
+	}
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		sub, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() || !patterns.MatchesPath(rel+"/"+filepath.ToSlash(sub)) {
+			return errNotLockable
+		}
+		return nil
+	})
+	return err == nil
+}
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 
@@ -11,6 +12,11 @@ import (
 func main() {
 	app := cmd.NewApp()
 	if err := app.Run(context.Background(), os.Args); err != nil {
+		// exec passes on its command's exit status silently
+		var status cmd.ExitStatus
+		if errors.As(err, &status) {
+			os.Exit(int(status))
+		}
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
 	}